@@ -0,0 +1,139 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// Attachment created for upload
+type Attachment struct {
+	FileID    string `json:"fileId"`
+	UploadURL string `json:"uploadUrl"`
+}
+
+// File message content
+type FileContent struct {
+	Type   string `json:"type"`
+	FileID string `json:"fileId"`
+}
+
+// Form field name of uploaded file
+const UPLOAD_FIELD_NAME = "Filedata"
+
+// Called while uploading with bytes sent so far and the total
+type ProgressFunc func(sent int64, total int64)
+
+// Create file message content. Type is "file" or "image".
+func NewFileContent(typ string, fileID string) FileContent {
+	return FileContent{Type: typ, FileID: fileID}
+}
+
+// Detect MIME type by the file extension, or by the content if the extension is unknown
+func DetectContentType(fileName string, data []byte) string {
+	if t := mime.TypeByExtension(strings.ToLower(filepath.Ext(fileName))); t != "" {
+		return t
+	}
+	return http.DetectContentType(data)
+}
+
+// Create an attachment of the bot to get the upload URL
+func (c *Client) CreateAttachment(ctx context.Context, botID string, fileName string) (*Attachment, error) {
+	path := fmt.Sprintf("bots/%s/attachments", url.PathEscape(botID))
+	attachment := &Attachment{}
+	err := c.Request(ctx, http.MethodPost, path, nil, map[string]string{"fileName": fileName}, attachment)
+	if err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// Upload the file to the upload URL of an attachment. progress can be nil.
+func (c *Client) UploadAttachment(ctx context.Context, uploadURL string, fileName string, data []byte, progress ProgressFunc) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	h := textproto.MIMEHeader{}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, UPLOAD_FIELD_NAME, escapeQuotes(filepath.Base(fileName))))
+	h.Set("Content-Type", DetectContentType(fileName, data))
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	body := buf.Bytes()
+
+	u, err := c.ResolveURL(uploadURL)
+	if err != nil {
+		return err
+	}
+	newBody := func() io.ReadCloser {
+		return io.NopCloser(&progressReader{r: bytes.NewReader(body), total: int64(len(body)), progress: progress})
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, newBody())
+	if err != nil {
+		return err
+	}
+	// Re-readable for retries
+	req.GetBody = func() (io.ReadCloser, error) { return newBody(), nil }
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", w.FormDataContentType())
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 400 {
+		return &Error{StatusCode: res.StatusCode, Status: res.Status, Body: b}
+	}
+	return nil
+}
+
+// Create an attachment and upload the file. Returns the file ID.
+func (c *Client) UploadFile(ctx context.Context, botID string, fileName string, data []byte, progress ProgressFunc) (string, error) {
+	attachment, err := c.CreateAttachment(ctx, botID, filepath.Base(fileName))
+	if err != nil {
+		return "", err
+	}
+	if err := c.UploadAttachment(ctx, attachment.UploadURL, fileName, data, progress); err != nil {
+		return "", err
+	}
+	return attachment.FileID, nil
+}
+
+type progressReader struct {
+	r        io.Reader
+	sent     int64
+	total    int64
+	progress ProgressFunc
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.sent += int64(n)
+	if p.progress != nil && n > 0 {
+		p.progress(p.sent, p.total)
+	}
+	return n, err
+}
+
+func escapeQuotes(s string) string {
+	return strings.NewReplacer("\\", "\\\\", `"`, "\\\"").Replace(s)
+}
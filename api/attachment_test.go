@@ -0,0 +1,88 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func TestDetectContentType(t *testing.T) {
+	cases := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"report.pdf", nil, "application/pdf"},
+		{"photo.PNG", nil, "image/png"},
+		{"noext", []byte("\x89PNG\r\n\x1a\n"), "image/png"},
+		{"noext", []byte("plain text"), "text/plain; charset=utf-8"},
+	}
+	for _, c := range cases {
+		if got := DetectContentType(c.name, c.data); got != c.want {
+			t.Errorf("DetectContentType(%s) = %s, want %s", c.name, got, c.want)
+		}
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	cases := []struct {
+		name     string
+		fileName string
+		data     string
+	}{
+		{"text file", "notes.txt", "hello"},
+		{"quoted name", `my "best" photo.png`, "\x89PNG\r\n\x1a\n"},
+		{"path is removed", "dir/report.pdf", "%PDF"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var srv *httptest.Server
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/v1.0/bots/b1/attachments":
+					req := map[string]string{}
+					json.NewDecoder(r.Body).Decode(&req)
+					if req["fileName"] != filepath.Base(c.fileName) {
+						t.Errorf("fileName = %s, want %s", req["fileName"], filepath.Base(c.fileName))
+					}
+					json.NewEncoder(w).Encode(Attachment{FileID: "f1", UploadURL: srv.URL + "/upload"})
+				case "/upload":
+					file, header, err := r.FormFile(UPLOAD_FIELD_NAME)
+					if err != nil {
+						t.Fatal(err)
+					}
+					b, _ := io.ReadAll(file)
+					if string(b) != c.data {
+						t.Errorf("uploaded %q, want %q", b, c.data)
+					}
+					if header.Filename != filepath.Base(c.fileName) {
+						t.Errorf("filename = %s, want %s", header.Filename, filepath.Base(c.fileName))
+					}
+				default:
+					t.Errorf("unexpected request %s", r.URL)
+					w.WriteHeader(http.StatusNotFound)
+				}
+			}))
+			defer srv.Close()
+
+			client := &Client{HTTPClient: srv.Client(), BaseURL: srv.URL + "/v1.0"}
+			var sent, total int64
+			fileID, err := client.UploadFile(context.Background(), "b1", c.fileName, []byte(c.data), func(s int64, t int64) {
+				sent, total = s, t
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if fileID != "f1" {
+				t.Errorf("fileID = %s, want f1", fileID)
+			}
+			if sent == 0 || sent != total {
+				t.Errorf("progress = %d / %d", sent, total)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+)
+
+// Max length of text message
+const MAX_TEXT_LENGTH = 2000
+
+// Text message content
+type TextContent struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Request body of message API
+type Message struct {
+	// Content object (ex. TextContent)
+	Content interface{} `json:"content"`
+}
+
+// Create text message content
+func NewTextContent(text string) TextContent {
+	return TextContent{Type: "text", Text: text}
+}
+
+// Validate text message
+func ValidateText(text string) error {
+	if text == "" {
+		return fmt.Errorf("text is empty")
+	}
+	if n := utf8.RuneCountInString(text); n > MAX_TEXT_LENGTH {
+		return fmt.Errorf("text is too long (%d characters). Max is %d", n, MAX_TEXT_LENGTH)
+	}
+	return nil
+}
+
+// Send message to a user
+func (c *Client) SendMessageToUser(ctx context.Context, botID string, userID string, content interface{}) error {
+	path := fmt.Sprintf("bots/%s/users/%s/messages", url.PathEscape(botID), url.PathEscape(userID))
+	return c.Request(ctx, http.MethodPost, path, nil, Message{Content: content}, nil)
+}
+
+// Send message to a channel
+func (c *Client) SendMessageToChannel(ctx context.Context, botID string, channelID string, content interface{}) error {
+	path := fmt.Sprintf("bots/%s/channels/%s/messages", url.PathEscape(botID), url.PathEscape(channelID))
+	return c.Request(ctx, http.MethodPost, path, nil, Message{Content: content}, nil)
+}
+
+// Split text into chunks within limit characters. Text is split at line breaks if possible.
+func SplitText(text string, limit int) []string {
+	chunks := []string{}
+	var current []rune
+	started := false
+	flush := func() {
+		if len(current) > 0 {
+			chunks = append(chunks, string(current))
+		}
+		current = nil
+		started = false
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		runes := []rune(line)
+		// Split a long line
+		for len(runes) > limit {
+			flush()
+			chunks = append(chunks, string(runes[:limit]))
+			runes = runes[limit:]
+		}
+		if started && len(current)+1+len(runes) > limit {
+			flush()
+		}
+		if started {
+			current = append(current, '\n')
+		}
+		current = append(current, runes...)
+		started = true
+	}
+	flush()
+	return chunks
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestSplitText(t *testing.T) {
+	cases := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{"short", "hello", 10, []string{"hello"}},
+		{"empty", "", 10, []string{}},
+		{"lines within limit", "ab\ncd", 5, []string{"ab\ncd"}},
+		{"split at line break", "abc\ndef\ng", 7, []string{"abc\ndef", "g"}},
+		{"long line", "abcdefgh", 3, []string{"abc", "def", "gh"}},
+		{"long line after short one", "a\nbcdefg", 3, []string{"a", "bcd", "efg"}},
+		{"multibyte", "あいうえお", 2, []string{"あい", "うえ", "お"}},
+		{"empty lines are kept", "a\n\nb", 10, []string{"a\n\nb"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := SplitText(c.text, c.limit)
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("SplitText() = %q, want %q", got, c.want)
+			}
+			for _, chunk := range got {
+				if n := utf8.RuneCountInString(chunk); n > c.limit {
+					t.Errorf("chunk %q has %d characters", chunk, n)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateText(t *testing.T) {
+	cases := []struct {
+		text string
+		ok   bool
+	}{
+		{"hello", true},
+		{"", false},
+		{strings.Repeat("あ", MAX_TEXT_LENGTH), true},
+		{strings.Repeat("a", MAX_TEXT_LENGTH+1), false},
+	}
+	for _, c := range cases {
+		if err := ValidateText(c.text); (err == nil) != c.ok {
+			t.Errorf("ValidateText(%d characters) = %v", utf8.RuneCountInString(c.text), err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Events which can be sent to the callback URL
+var CallbackEvents = []string{"text", "location", "sticker", "image", "file"}
+
+// Validate bot settings before register or update. Only set fields are checked on update.
+func ValidateBotSettings(settings map[string]interface{}, create bool) error {
+	problems := []string{}
+	if create {
+		for _, k := range []string{"botName", "photoUrl"} {
+			if s, _ := settings[k].(string); s == "" {
+				problems = append(problems, fmt.Sprintf("%s: required", k))
+			}
+		}
+	}
+	for _, k := range []string{"photoUrl", "callbackUrl"} {
+		s, _ := settings[k].(string)
+		if s == "" {
+			continue
+		}
+		if u, err := url.Parse(s); err != nil || u.Scheme != "https" || u.Host == "" {
+			problems = append(problems, fmt.Sprintf("%s: must be an https URL", k))
+		}
+	}
+	if events, ok := settings["callbackEvents"].([]interface{}); ok {
+		for i, e := range events {
+			if !containsString(CallbackEvents, fmt.Sprint(e)) {
+				problems = append(problems, fmt.Sprintf("callbackEvents[%d]: unknown event '%v'. Must be one of %s", i, e, strings.Join(CallbackEvents, ", ")))
+			}
+		}
+	}
+	if enabled, _ := settings["enableCallback"].(bool); enabled && create {
+		if s, _ := settings["callbackUrl"].(string); s == "" {
+			problems = append(problems, "callbackUrl: required when callback is enabled")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid bot settings\n  %s", strings.Join(problems, "\n  "))
+	}
+	return nil
+}
+
+// Get a bot
+func (c *Client) GetBot(ctx context.Context, botID string) (map[string]interface{}, error) {
+	bot := map[string]interface{}{}
+	err := c.Request(ctx, http.MethodGet, "bots/"+url.PathEscape(botID), nil, nil, &bot)
+	return bot, err
+}
+
+// Register a bot. The response includes botId and botSecret.
+func (c *Client) CreateBot(ctx context.Context, settings map[string]interface{}) (map[string]interface{}, error) {
+	bot := map[string]interface{}{}
+	err := c.Request(ctx, http.MethodPost, "bots", nil, settings, &bot)
+	return bot, err
+}
+
+// Update set fields of a bot
+func (c *Client) UpdateBot(ctx context.Context, botID string, settings map[string]interface{}) (map[string]interface{}, error) {
+	bot := map[string]interface{}{}
+	err := c.Request(ctx, http.MethodPatch, "bots/"+url.PathEscape(botID), nil, settings, &bot)
+	return bot, err
+}
+
+// Delete a bot
+func (c *Client) DeleteBot(ctx context.Context, botID string) error {
+	return c.Request(ctx, http.MethodDelete, "bots/"+url.PathEscape(botID), nil, nil, nil)
+}
+
+func botDomainPath(botID string, domainID string) string {
+	return fmt.Sprintf("bots/%s/domains/%s", url.PathEscape(botID), url.PathEscape(domainID))
+}
+
+// Get the domain registration of a bot
+func (c *Client) GetBotDomain(ctx context.Context, botID string, domainID string) (map[string]interface{}, error) {
+	domain := map[string]interface{}{}
+	err := c.Request(ctx, http.MethodGet, botDomainPath(botID, domainID), nil, nil, &domain)
+	return domain, err
+}
+
+// Register a bot to the domain
+func (c *Client) RegisterBotDomain(ctx context.Context, botID string, domainID string, settings map[string]interface{}) (map[string]interface{}, error) {
+	domain := map[string]interface{}{}
+	err := c.Request(ctx, http.MethodPost, botDomainPath(botID, domainID), nil, settings, &domain)
+	return domain, err
+}
+
+// Unregister a bot from the domain
+func (c *Client) UnregisterBotDomain(ctx context.Context, botID string, domainID string) error {
+	return c.Request(ctx, http.MethodDelete, botDomainPath(botID, domainID), nil, nil, nil)
+}
+
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
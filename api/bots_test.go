@@ -0,0 +1,46 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateBotSettings(t *testing.T) {
+	valid := map[string]interface{}{"botName": "Bot", "photoUrl": "https://example.com/a.png"}
+	cases := []struct {
+		name     string
+		settings map[string]interface{}
+		create   bool
+		problems []string
+	}{
+		{"valid", valid, true, nil},
+		{"required on create", map[string]interface{}{}, true, []string{"botName: required", "photoUrl: required"}},
+		{"not required on update", map[string]interface{}{"description": "d"}, false, nil},
+		{"http photo URL", map[string]interface{}{"photoUrl": "http://example.com/a.png"}, false, []string{"photoUrl: must be an https URL"}},
+		{"callback URL without host", map[string]interface{}{"callbackUrl": "https://"}, false, []string{"callbackUrl: must be an https URL"}},
+		{"known callback events", map[string]interface{}{"callbackEvents": []interface{}{"text", "file"}}, false, nil},
+		{"unknown callback event", map[string]interface{}{"callbackEvents": []interface{}{"text", "video"}}, false, []string{"callbackEvents[1]: unknown event 'video'"}},
+		{"callback enabled without URL on create", map[string]interface{}{"botName": "Bot", "photoUrl": "https://example.com/a.png", "enableCallback": true}, true,
+			[]string{"callbackUrl: required when callback is enabled"}},
+		{"callback enabled without URL on update", map[string]interface{}{"enableCallback": true}, false, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			err := ValidateBotSettings(c.settings, c.create)
+			if len(c.problems) == 0 {
+				if err != nil {
+					t.Errorf("ValidateBotSettings() = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("ValidateBotSettings() = nil, want %v", c.problems)
+			}
+			for _, p := range c.problems {
+				if !strings.Contains(err.Error(), p) {
+					t.Errorf("error %q does not contain %q", err, p)
+				}
+			}
+		})
+	}
+}
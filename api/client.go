@@ -0,0 +1,137 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+const BaseURL = "https://www.worksapis.com/v1.0"
+
+// Environment variable to override the API base URL (ex. http://127.0.0.1:9080/v1.0 for mock server)
+const BASE_URL_ENV_NAME = "LINEWORKS_API_BASE_URL"
+
+// Get API base URL. It can be overridden by LINEWORKS_API_BASE_URL.
+func GetBaseURL() string {
+	if base := os.Getenv(BASE_URL_ENV_NAME); base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	return BaseURL
+}
+
+// Client for LINE WORKS API
+type Client struct {
+	// Authenticated HTTP client (ex. auth.NewClient)
+	HTTPClient *http.Client
+	BaseURL    string
+}
+
+// Error response of API
+type Error struct {
+	StatusCode int
+	Status     string
+	Body       []byte
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("Error: status code %d, body %s", e.StatusCode, e.Body)
+}
+
+// Create API client
+func NewClient(httpClient *http.Client) *Client {
+	return &Client{
+		HTTPClient: httpClient,
+		BaseURL:    GetBaseURL(),
+	}
+}
+
+// Resolve the path against the base URL.
+// Both "users/me" and "/v1.0/users/me" are resolved to "https://www.worksapis.com/v1.0/users/me".
+func (c *Client) ResolveURL(path string) (string, error) {
+	if u, err := url.Parse(path); err == nil && u.Scheme != "" {
+		return path, nil
+	}
+
+	base, err := url.Parse(c.BaseURL)
+	if err != nil {
+		return "", err
+	}
+	basePath := strings.Trim(base.Path, "/")
+	path = strings.TrimPrefix(path, "/")
+	if basePath != "" && (path == basePath || strings.HasPrefix(path, basePath+"/")) {
+		path = strings.TrimPrefix(strings.TrimPrefix(path, basePath), "/")
+	}
+
+	ref, err := url.Parse(path)
+	if err != nil {
+		return "", err
+	}
+	u := *base
+	u.Path = "/" + strings.Trim(basePath+"/"+ref.Path, "/")
+	u.RawQuery = ref.RawQuery
+	return u.String(), nil
+}
+
+// Send request. The response is returned as is, even if it is an error response.
+func (c *Client) Do(ctx context.Context, method string, path string, body io.Reader, header http.Header) (*http.Response, error) {
+	u, err := c.ResolveURL(path)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	return c.HTTPClient.Do(req)
+}
+
+// Send JSON request and decode JSON response.
+// reqBody and resBody can be nil. Error responses are returned as *Error.
+func (c *Client) Request(ctx context.Context, method string, path string, query url.Values, reqBody interface{}, resBody interface{}) error {
+	var body io.Reader
+	if reqBody != nil {
+		b, err := json.Marshal(reqBody)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(b)
+	}
+	if len(query) > 0 {
+		sep := "?"
+		if strings.Contains(path, "?") {
+			sep = "&"
+		}
+		path = path + sep + query.Encode()
+	}
+
+	res, err := c.Do(ctx, method, path, body, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode >= 400 {
+		return &Error{StatusCode: res.StatusCode, Status: res.Status, Body: b}
+	}
+	if resBody == nil || len(b) == 0 {
+		return nil
+	}
+	return json.Unmarshal(b, resBody)
+}
@@ -0,0 +1,407 @@
+package api
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Error of message content validation. Path points at the bad field (ex. content.actions[1].uri).
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// All errors found in message content
+type ValidationErrors []*ValidationError
+
+func (errs ValidationErrors) Error() string {
+	return "invalid message content\n  " + strings.Join(errs.messages(), "\n  ")
+}
+
+func (errs ValidationErrors) messages() []string {
+	lines := make([]string, len(errs))
+	for i, e := range errs {
+		lines[i] = e.Error()
+	}
+	return lines
+}
+
+// Content types and their validators
+var contentTypes = map[string]func(v *contentValidator, path string, obj map[string]interface{}){
+	"text":            validateTextContent,
+	"image":           validateImageContent,
+	"file":            validateFileContent,
+	"link":            validateLinkContent,
+	"sticker":         validateStickerContent,
+	"button_template": validateButtonTemplate,
+	"list_template":   validateListTemplate,
+	"carousel":        validateCarousel,
+	"image_carousel":  validateImageCarousel,
+	"flex":            validateFlex,
+}
+
+var actionTypes = map[string][]string{
+	"message":    {"type", "label", "text", "postback", "i18nLabels", "i18nTexts"},
+	"uri":        {"type", "label", "uri", "i18nLabels"},
+	"postback":   {"type", "label", "data", "displayText", "i18nLabels", "i18nDisplayTexts"},
+	"camera":     {"type", "label", "i18nLabels"},
+	"cameraRoll": {"type", "label", "i18nLabels"},
+	"location":   {"type", "label", "i18nLabels"},
+	"copy":       {"type", "label", "copyText", "i18nLabels"},
+}
+
+// Names of supported content types
+func ContentTypes() []string {
+	types := []string{}
+	for k := range contentTypes {
+		types = append(types, k)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// Validate message content against the LINE WORKS content schema.
+// Returns ValidationErrors with all bad fields.
+func ValidateContent(content map[string]interface{}) error {
+	v := &contentValidator{}
+	typ, ok := v.str(content, "content", "type", true, 0)
+	if ok {
+		validate, known := contentTypes[typ]
+		if known {
+			validate(v, "content", content)
+		} else {
+			v.fail("content.type", "unknown type '%s'. Must be one of %s", typ, strings.Join(ContentTypes(), ", "))
+		}
+	}
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+func validateTextContent(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "text", "i18nTexts", "quickReply")
+	v.str(obj, path, "text", true, MAX_TEXT_LENGTH)
+	v.quickReply(obj, path)
+}
+
+func validateImageContent(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "previewImageUrl", "originalContentUrl", "fileId", "quickReply")
+	v.url(obj, path, "previewImageUrl", false)
+	v.url(obj, path, "originalContentUrl", false)
+	v.str(obj, path, "fileId", false, 0)
+	v.oneOf(obj, path, "originalContentUrl", "fileId")
+	v.quickReply(obj, path)
+}
+
+func validateFileContent(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "originalContentUrl", "fileId", "quickReply")
+	v.url(obj, path, "originalContentUrl", false)
+	v.str(obj, path, "fileId", false, 0)
+	v.oneOf(obj, path, "originalContentUrl", "fileId")
+	v.quickReply(obj, path)
+}
+
+func validateLinkContent(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "contentText", "linkText", "link", "i18nContentTexts", "i18nLinkTexts", "quickReply")
+	v.str(obj, path, "contentText", true, 0)
+	v.str(obj, path, "linkText", true, 0)
+	v.url(obj, path, "link", true)
+	v.quickReply(obj, path)
+}
+
+func validateStickerContent(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "packageId", "stickerId", "quickReply")
+	v.str(obj, path, "packageId", true, 0)
+	v.str(obj, path, "stickerId", true, 0)
+	v.quickReply(obj, path)
+}
+
+func validateButtonTemplate(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "contentText", "i18nContentTexts", "actions", "quickReply")
+	v.str(obj, path, "contentText", true, 0)
+	for i, a := range v.objects(obj, path, "actions", true, 1, 10) {
+		v.action(a, fmt.Sprintf("%s.actions[%d]", path, i), true)
+	}
+	v.quickReply(obj, path)
+}
+
+func validateListTemplate(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "coverData", "elements", "actions", "quickReply")
+	if cover, ok := v.object(obj, path, "coverData", false); ok {
+		coverPath := path + ".coverData"
+		v.fields(cover, coverPath, "backgroundImageUrl", "backgroundFileId", "title", "subtitle", "defaultAction")
+		v.url(cover, coverPath, "backgroundImageUrl", false)
+		v.str(cover, coverPath, "title", false, 0)
+		v.str(cover, coverPath, "subtitle", false, 0)
+		if a, ok := v.object(cover, coverPath, "defaultAction", false); ok {
+			v.action(a, coverPath+".defaultAction", false)
+		}
+	}
+	for i, e := range v.objects(obj, path, "elements", true, 1, 4) {
+		elemPath := fmt.Sprintf("%s.elements[%d]", path, i)
+		v.fields(e, elemPath, "title", "subtitle", "originalContentUrl", "fileId", "action")
+		v.str(e, elemPath, "title", true, 0)
+		v.str(e, elemPath, "subtitle", false, 0)
+		v.url(e, elemPath, "originalContentUrl", false)
+		if a, ok := v.object(e, elemPath, "action", false); ok {
+			v.action(a, elemPath+".action", true)
+		}
+	}
+	if rows, ok := v.array(obj, path, "actions", false, 0, 2); ok {
+		for i, row := range rows {
+			rowPath := fmt.Sprintf("%s.actions[%d]", path, i)
+			actions, ok := row.([]interface{})
+			if !ok {
+				v.fail(rowPath, "must be an array of actions")
+				continue
+			}
+			for j, a := range actions {
+				actionPath := fmt.Sprintf("%s[%d]", rowPath, j)
+				if m, ok := a.(map[string]interface{}); ok {
+					v.action(m, actionPath, true)
+				} else {
+					v.fail(actionPath, "must be an object")
+				}
+			}
+		}
+	}
+	v.quickReply(obj, path)
+}
+
+func validateCarousel(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "imageAspectRatio", "imageSize", "columns", "quickReply")
+	v.enum(obj, path, "imageAspectRatio", "rectangle", "square")
+	v.enum(obj, path, "imageSize", "cover", "contain")
+	for i, c := range v.objects(obj, path, "columns", true, 1, 10) {
+		colPath := fmt.Sprintf("%s.columns[%d]", path, i)
+		v.fields(c, colPath, "originalContentUrl", "fileId", "title", "text", "defaultAction", "actions", "i18nTitles", "i18nTexts")
+		v.url(c, colPath, "originalContentUrl", false)
+		v.str(c, colPath, "fileId", false, 0)
+		v.str(c, colPath, "title", false, 0)
+		v.str(c, colPath, "text", true, 0)
+		if a, ok := v.object(c, colPath, "defaultAction", false); ok {
+			v.action(a, colPath+".defaultAction", false)
+		}
+		for j, a := range v.objects(c, colPath, "actions", true, 1, 3) {
+			v.action(a, fmt.Sprintf("%s.actions[%d]", colPath, j), true)
+		}
+	}
+	v.quickReply(obj, path)
+}
+
+func validateImageCarousel(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "columns", "quickReply")
+	for i, c := range v.objects(obj, path, "columns", true, 1, 10) {
+		colPath := fmt.Sprintf("%s.columns[%d]", path, i)
+		v.fields(c, colPath, "originalContentUrl", "fileId", "action")
+		v.url(c, colPath, "originalContentUrl", false)
+		v.str(c, colPath, "fileId", false, 0)
+		v.oneOf(c, colPath, "originalContentUrl", "fileId")
+		if a, ok := v.object(c, colPath, "action", false); ok {
+			v.action(a, colPath+".action", false)
+		}
+	}
+	v.quickReply(obj, path)
+}
+
+func validateFlex(v *contentValidator, path string, obj map[string]interface{}) {
+	v.fields(obj, path, "type", "altText", "contents", "quickReply")
+	v.str(obj, path, "altText", true, 0)
+	if contents, ok := v.object(obj, path, "contents", true); ok {
+		v.enum(contents, path+".contents", "type", "bubble", "carousel")
+		if _, ok := contents["type"]; !ok {
+			v.fail(path+".contents.type", "required")
+		}
+	}
+	v.quickReply(obj, path)
+}
+
+type contentValidator struct {
+	errs ValidationErrors
+}
+
+func (v *contentValidator) fail(path string, format string, a ...interface{}) {
+	v.errs = append(v.errs, &ValidationError{Path: path, Message: fmt.Sprintf(format, a...)})
+}
+
+// Check there are no unknown fields
+func (v *contentValidator) fields(obj map[string]interface{}, path string, allowed ...string) {
+	known := map[string]bool{}
+	for _, k := range allowed {
+		known[k] = true
+	}
+	keys := []string{}
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if !known[k] {
+			v.fail(path+"."+k, "unknown field. Must be one of %s", strings.Join(allowed, ", "))
+		}
+	}
+}
+
+func (v *contentValidator) str(obj map[string]interface{}, path string, key string, required bool, maxLen int) (string, bool) {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		if required {
+			v.fail(path+"."+key, "required")
+		}
+		return "", false
+	}
+	s, ok := value.(string)
+	if !ok {
+		v.fail(path+"."+key, "must be a string")
+		return "", false
+	}
+	if required && s == "" {
+		v.fail(path+"."+key, "must not be empty")
+		return "", false
+	}
+	if maxLen > 0 {
+		if n := utf8.RuneCountInString(s); n > maxLen {
+			v.fail(path+"."+key, "too long (%d characters). Max is %d", n, maxLen)
+		}
+	}
+	return s, true
+}
+
+func (v *contentValidator) url(obj map[string]interface{}, path string, key string, required bool) {
+	s, ok := v.str(obj, path, key, required, 0)
+	if !ok || s == "" {
+		return
+	}
+	if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.fail(path+"."+key, "must be an http(s) URL")
+	}
+}
+
+func (v *contentValidator) enum(obj map[string]interface{}, path string, key string, values ...string) {
+	s, ok := v.str(obj, path, key, false, 0)
+	if !ok {
+		return
+	}
+	for _, e := range values {
+		if s == e {
+			return
+		}
+	}
+	v.fail(path+"."+key, "'%s' is invalid. Must be one of %s", s, strings.Join(values, ", "))
+}
+
+// Check at least one of the keys is set
+func (v *contentValidator) oneOf(obj map[string]interface{}, path string, keys ...string) {
+	for _, k := range keys {
+		if s, ok := obj[k].(string); ok && s != "" {
+			return
+		}
+	}
+	v.fail(path, "one of %s is required", strings.Join(keys, ", "))
+}
+
+func (v *contentValidator) object(obj map[string]interface{}, path string, key string, required bool) (map[string]interface{}, bool) {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		if required {
+			v.fail(path+"."+key, "required")
+		}
+		return nil, false
+	}
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		v.fail(path+"."+key, "must be an object")
+		return nil, false
+	}
+	return m, true
+}
+
+// Check the array length is within min and max. max 0 means no limit.
+func (v *contentValidator) array(obj map[string]interface{}, path string, key string, required bool, min int, max int) ([]interface{}, bool) {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		if required {
+			v.fail(path+"."+key, "required")
+		}
+		return nil, false
+	}
+	a, ok := value.([]interface{})
+	if !ok {
+		v.fail(path+"."+key, "must be an array")
+		return nil, false
+	}
+	if len(a) < min || (max > 0 && len(a) > max) {
+		v.fail(path+"."+key, "must have %d to %d items, but has %d", min, max, len(a))
+	}
+	return a, true
+}
+
+// Array of objects. Items which are not objects are reported and skipped.
+func (v *contentValidator) objects(obj map[string]interface{}, path string, key string, required bool, min int, max int) []map[string]interface{} {
+	a, ok := v.array(obj, path, key, required, min, max)
+	if !ok {
+		return nil
+	}
+	objs := []map[string]interface{}{}
+	for i, item := range a {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			v.fail(fmt.Sprintf("%s.%s[%d]", path, key, i), "must be an object")
+			continue
+		}
+		objs = append(objs, m)
+	}
+	return objs
+}
+
+func (v *contentValidator) action(obj map[string]interface{}, path string, labelRequired bool) {
+	typ, ok := v.str(obj, path, "type", true, 0)
+	if !ok {
+		return
+	}
+	allowed, known := actionTypes[typ]
+	if !known {
+		types := []string{}
+		for k := range actionTypes {
+			types = append(types, k)
+		}
+		sort.Strings(types)
+		v.fail(path+".type", "unknown action type '%s'. Must be one of %s", typ, strings.Join(types, ", "))
+		return
+	}
+	v.fields(obj, path, allowed...)
+	v.str(obj, path, "label", labelRequired, 0)
+	switch typ {
+	case "uri":
+		v.url(obj, path, "uri", true)
+	case "postback":
+		v.str(obj, path, "data", true, 0)
+	case "copy":
+		v.str(obj, path, "copyText", true, 0)
+	}
+}
+
+func (v *contentValidator) quickReply(obj map[string]interface{}, path string) {
+	qr, ok := v.object(obj, path, "quickReply", false)
+	if !ok {
+		return
+	}
+	qrPath := path + ".quickReply"
+	v.fields(qr, qrPath, "items")
+	for i, item := range v.objects(qr, qrPath, "items", true, 1, 13) {
+		itemPath := fmt.Sprintf("%s.items[%d]", qrPath, i)
+		v.fields(item, itemPath, "imageUrl", "fileId", "action")
+		v.url(item, itemPath, "imageUrl", false)
+		if a, ok := v.object(item, itemPath, "action", true); ok {
+			v.action(a, itemPath+".action", true)
+		}
+	}
+}
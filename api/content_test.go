@@ -0,0 +1,81 @@
+package api
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateContent(t *testing.T) {
+	cases := []struct {
+		name    string
+		content string
+		// Paths of expected errors
+		errs []string
+	}{
+		{"text", `{"type":"text","text":"hello"}`, nil},
+		{"empty text", `{"type":"text","text":""}`, []string{"content.text"}},
+		{"too long text", `{"type":"text","text":"` + strings.Repeat("a", MAX_TEXT_LENGTH+1) + `"}`, []string{"content.text"}},
+		{"no type", `{"text":"hello"}`, []string{"content.type"}},
+		{"unknown type", `{"type":"video"}`, []string{"content.type"}},
+		{"unknown field", `{"type":"text","text":"hello","color":"red"}`, []string{"content.color"}},
+		{"image by file ID", `{"type":"image","fileId":"f"}`, nil},
+		{"image without source", `{"type":"image","previewImageUrl":"https://example.com/a.png"}`, []string{"content"}},
+		{"image with bad URL", `{"type":"image","originalContentUrl":"ftp://example.com/a.png"}`, []string{"content.originalContentUrl"}},
+		{
+			"button template",
+			`{"type":"button_template","contentText":"Pick","actions":[{"type":"message","label":"Yes","text":"yes"},{"type":"uri","label":"Docs","uri":"https://example.com"}]}`,
+			nil,
+		},
+		{
+			"button template with bad actions",
+			`{"type":"button_template","contentText":"Pick","actions":[{"type":"message","text":"yes"},{"type":"call","label":"Call"},{"type":"uri","label":"Docs"}]}`,
+			[]string{"content.actions[0].label", "content.actions[1].type", "content.actions[2].uri"},
+		},
+		{"button template without actions", `{"type":"button_template","contentText":"Pick","actions":[]}`, []string{"content.actions"}},
+		{
+			"list template with too many elements",
+			`{"type":"list_template","elements":[{"title":"1"},{"title":"2"},{"title":"3"},{"title":"4"},{"title":"5"}]}`,
+			[]string{"content.elements"},
+		},
+		{
+			"carousel",
+			`{"type":"carousel","imageAspectRatio":"wide","columns":[{"text":"a","actions":[{"type":"postback","label":"A"}]}]}`,
+			[]string{"content.imageAspectRatio", "content.columns[0].actions[0].data"},
+		},
+		{"flex", `{"type":"flex","altText":"alt","contents":{"type":"bubble"}}`, nil},
+		{"flex without contents type", `{"type":"flex","altText":"alt","contents":{}}`, []string{"content.contents.type"}},
+		{
+			"quick reply",
+			`{"type":"text","text":"hello","quickReply":{"items":[{"action":{"type":"message","label":"Hi","text":"hi"}},{"imageUrl":"x"}]}}`,
+			[]string{"content.quickReply.items[1].imageUrl", "content.quickReply.items[1].action"},
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			content := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(c.content), &content); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateContent(content)
+			if c.errs == nil {
+				if err != nil {
+					t.Fatalf("ValidateContent() = %v", err)
+				}
+				return
+			}
+			errs, ok := err.(ValidationErrors)
+			if !ok {
+				t.Fatalf("ValidateContent() = %v, want ValidationErrors", err)
+			}
+			paths := []string{}
+			for _, e := range errs {
+				paths = append(paths, e.Path)
+			}
+			if !reflect.DeepEqual(paths, c.errs) {
+				t.Errorf("error paths = %v, want %v\n%v", paths, c.errs, err)
+			}
+		})
+	}
+}
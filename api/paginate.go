@@ -0,0 +1,138 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// Stop pagination without error
+var ErrStopPagination = errors.New("stop pagination")
+
+// Returned when the server returns a cursor which was already followed, so that pagination does not loop forever
+var ErrRepeatedCursor = errors.New("pagination cursor is repeated")
+
+type responseMetaData struct {
+	NextCursor string `json:"nextCursor"`
+}
+
+// Follow cursors of a list API and call fn for each item.
+// Items are taken from the array field of the response. Pagination stops after limit items if limit > 0.
+func (c *Client) Paginate(ctx context.Context, path string, limit int, fn func(item json.RawMessage) error) error {
+	return c.PaginateWithHeader(ctx, path, nil, limit, fn)
+}
+
+// Paginate with additional headers sent on every page request
+func (c *Client) PaginateWithHeader(ctx context.Context, path string, header http.Header, limit int, fn func(item json.RawMessage) error) error {
+	u, err := url.Parse(path)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	key := itemsKey(u.Path)
+
+	count := 0
+	seen := map[string]bool{}
+	for {
+		u.RawQuery = query.Encode()
+		page, next, err := c.getPage(ctx, u.String(), header, key)
+		if err != nil {
+			return err
+		}
+
+		for _, item := range page {
+			if err := fn(item); errors.Is(err, ErrStopPagination) {
+				return nil
+			} else if err != nil {
+				return err
+			}
+			count++
+			if limit > 0 && count >= limit {
+				return nil
+			}
+		}
+
+		if next == "" {
+			return nil
+		}
+		if seen[next] {
+			return fmt.Errorf("%w: %s", ErrRepeatedCursor, next)
+		}
+		seen[next] = true
+		query.Set("cursor", next)
+	}
+}
+
+// Get a page. Returns items and next cursor.
+func (c *Client) getPage(ctx context.Context, path string, header http.Header, key string) ([]json.RawMessage, string, error) {
+	res, err := c.Do(ctx, http.MethodGet, path, nil, header)
+	if err != nil {
+		return nil, "", err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, "", err
+	}
+	if res.StatusCode >= 400 {
+		return nil, "", &Error{StatusCode: res.StatusCode, Status: res.Status, Body: b}
+	}
+
+	return parsePage(b, key)
+}
+
+// Field name of items guessed from the path (ex. "users" for /v1.0/users)
+func itemsKey(path string) string {
+	segs := strings.Split(strings.Trim(path, "/"), "/")
+	return segs[len(segs)-1]
+}
+
+// Parse a page of a list API. Items are the only array field, or the field of key if there are multiple.
+func parsePage(body []byte, key string) ([]json.RawMessage, string, error) {
+	fields := map[string]json.RawMessage{}
+	if err := json.Unmarshal(body, &fields); err != nil {
+		return nil, "", err
+	}
+
+	meta := responseMetaData{}
+	if raw, ok := fields["responseMetaData"]; ok {
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, "", err
+		}
+	}
+
+	arrays := []string{}
+	for k, raw := range fields {
+		if k != "responseMetaData" && strings.HasPrefix(strings.TrimSpace(string(raw)), "[") {
+			arrays = append(arrays, k)
+		}
+	}
+	sort.Strings(arrays)
+
+	var raw json.RawMessage
+	switch {
+	case len(arrays) == 0:
+		return nil, "", errors.New("response does not contain a list")
+	case len(arrays) == 1:
+		raw = fields[arrays[0]]
+	default:
+		r, ok := fields[key]
+		if !ok || !strings.HasPrefix(strings.TrimSpace(string(r)), "[") {
+			return nil, "", fmt.Errorf("response contains multiple lists (%s)", strings.Join(arrays, ", "))
+		}
+		raw = r
+	}
+
+	items := []json.RawMessage{}
+	if err := json.Unmarshal(raw, &items); err != nil {
+		return nil, "", err
+	}
+	return items, meta.NextCursor, nil
+}
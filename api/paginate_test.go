@@ -0,0 +1,205 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  string
+		key   string
+		items []string
+		next  string
+		err   bool
+	}{
+		{
+			"single list",
+			`{"users":[{"id":1},{"id":2}],"responseMetaData":{"nextCursor":"c2"}}`,
+			"users",
+			[]string{`{"id":1}`, `{"id":2}`},
+			"c2",
+			false,
+		},
+		{
+			"single list with another name",
+			`{"members":[1],"responseMetaData":{"nextCursor":null}}`,
+			"users",
+			[]string{`1`},
+			"",
+			false,
+		},
+		{
+			"multiple lists picked by key",
+			`{"aliases":["a"],"users":[{"id":1}],"tags":["t"]}`,
+			"users",
+			[]string{`{"id":1}`},
+			"",
+			false,
+		},
+		{"multiple lists without key", `{"aliases":["a"],"tags":["t"]}`, "users", nil, "", true},
+		{"no list", `{"userId":"u"}`, "users", nil, "", true},
+		{"empty list", `{"users":[]}`, "users", []string{}, "", false},
+		{"invalid JSON", `[`, "users", nil, "", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			items, next, err := parsePage([]byte(c.body), c.key)
+			if c.err {
+				if err == nil {
+					t.Fatal("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := []string{}
+			for _, item := range items {
+				got = append(got, string(item))
+			}
+			if !reflect.DeepEqual(got, c.items) {
+				t.Errorf("items = %v, want %v", got, c.items)
+			}
+			if next != c.next {
+				t.Errorf("next = %q, want %q", next, c.next)
+			}
+		})
+	}
+}
+
+func TestItemsKey(t *testing.T) {
+	cases := map[string]string{
+		"users":                       "users",
+		"/v1.0/users":                 "users",
+		"bots/2000001/richmenus":      "richmenus",
+		"/v1.0/bots/2000001/members/": "members",
+	}
+	for path, want := range cases {
+		if got := itemsKey(path); got != want {
+			t.Errorf("itemsKey(%s) = %s, want %s", path, got, want)
+		}
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	// 3 pages of 2 users
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Test") != "1" {
+			t.Errorf("header is not sent on %s", r.URL)
+		}
+		if r.URL.Query().Get("count") != "2" {
+			t.Errorf("query is not kept on %s", r.URL)
+		}
+		page := 0
+		fmt.Sscan(r.URL.Query().Get("cursor"), &page)
+		next := ""
+		if page < 2 {
+			next = fmt.Sprint(page + 1)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"users":            []int{page*2 + 1, page*2 + 2},
+			"responseMetaData": map[string]string{"nextCursor": next},
+		})
+	}))
+	defer srv.Close()
+
+	cases := []struct {
+		name  string
+		limit int
+		want  []string
+	}{
+		{"all pages", 0, []string{"1", "2", "3", "4", "5", "6"}},
+		{"limit within a page", 3, []string{"1", "2", "3"}},
+		{"limit over all items", 10, []string{"1", "2", "3", "4", "5", "6"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			client := &Client{HTTPClient: srv.Client(), BaseURL: srv.URL + "/v1.0"}
+			header := http.Header{"X-Test": {"1"}}
+			got := []string{}
+			err := client.PaginateWithHeader(context.Background(), "users?count=2", header, c.limit, func(item json.RawMessage) error {
+				got = append(got, string(item))
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("items = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestPaginateStop(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"users":[1,2,3],"responseMetaData":{"nextCursor":"next"}}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{HTTPClient: srv.Client(), BaseURL: srv.URL}
+	n := 0
+	err := client.Paginate(context.Background(), "users", 0, func(item json.RawMessage) error {
+		n++
+		if n == 2 {
+			return ErrStopPagination
+		}
+		return nil
+	})
+	if err != nil || n != 2 {
+		t.Errorf("Paginate() = %v after %d items, want nil after 2", err, n)
+	}
+}
+
+func TestPaginateError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"code":"FORBIDDEN"}`)
+	}))
+	defer srv.Close()
+
+	client := &Client{HTTPClient: srv.Client(), BaseURL: srv.URL}
+	err := client.Paginate(context.Background(), "users", 0, func(item json.RawMessage) error { return nil })
+	apiErr, ok := err.(*Error)
+	if !ok || apiErr.StatusCode != http.StatusForbidden {
+		t.Errorf("Paginate() = %v, want 403 error", err)
+	}
+}
+
+func TestPaginateRepeatedCursor(t *testing.T) {
+	cases := []struct {
+		name    string
+		cursors map[string]string
+		pages   int
+	}{
+		{"same cursor", map[string]string{"": "a", "a": "a"}, 2},
+		{"cursor of an earlier page", map[string]string{"": "a", "a": "b", "b": "a"}, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			pages := 0
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				pages++
+				fmt.Fprintf(w, `{"users":[1],"responseMetaData":{"nextCursor":"%s"}}`, c.cursors[r.URL.Query().Get("cursor")])
+			}))
+			defer srv.Close()
+
+			client := &Client{HTTPClient: srv.Client(), BaseURL: srv.URL}
+			err := client.Paginate(context.Background(), "users", 0, func(item json.RawMessage) error { return nil })
+			if !errors.Is(err, ErrRepeatedCursor) {
+				t.Errorf("Paginate() = %v, want ErrRepeatedCursor", err)
+			}
+			if pages != c.pages {
+				t.Errorf("got %d pages, want %d", pages, c.pages)
+			}
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Max number of areas in a rich menu
+const MAX_RICHMENU_AREAS = 20
+
+// Max length of rich menu name
+const MAX_RICHMENU_NAME_LENGTH = 300
+
+// Size of a rich menu image in pixels
+type ImageSize struct {
+	Width  int
+	Height int
+}
+
+// Path of the rich menus of a bot, or of elem under them. Each element is escaped.
+func RichmenuPath(botID string, elem ...string) string {
+	path := fmt.Sprintf("bots/%s/richmenus", url.PathEscape(botID))
+	for _, e := range elem {
+		path += "/" + url.PathEscape(e)
+	}
+	return path
+}
+
+// Validate a rich menu definition. If image is not nil, the size and area bounds are checked against it.
+func ValidateRichmenu(menu map[string]interface{}, image *ImageSize) error {
+	v := &contentValidator{}
+	path := "richmenu"
+	// Top-level fields are not checked, so that menus got from the API can be validated
+	v.str(menu, path, "richmenuName", true, MAX_RICHMENU_NAME_LENGTH)
+
+	// Bounds of areas must be within the menu size, or the image size if the menu has no size
+	var limit *ImageSize
+	if size, ok := v.object(menu, path, "size", true); ok {
+		sizePath := path + ".size"
+		v.fields(size, sizePath, "width", "height")
+		width, wok := v.pixels(size, sizePath, "width", true)
+		height, hok := v.pixels(size, sizePath, "height", true)
+		if wok && hok {
+			limit = &ImageSize{Width: width, Height: height}
+			if image != nil && *limit != *image {
+				v.fail(sizePath, "%dx%d does not match the image size %dx%d", width, height, image.Width, image.Height)
+			}
+		}
+	}
+	if limit == nil {
+		limit = image
+	}
+
+	for i, area := range v.objects(menu, path, "areas", true, 1, MAX_RICHMENU_AREAS) {
+		areaPath := fmt.Sprintf("%s.areas[%d]", path, i)
+		v.fields(area, areaPath, "bounds", "action")
+		if a, ok := v.object(area, areaPath, "action", true); ok {
+			v.action(a, areaPath+".action", false)
+		}
+		bounds, ok := v.object(area, areaPath, "bounds", true)
+		if !ok {
+			continue
+		}
+		boundsPath := areaPath + ".bounds"
+		v.fields(bounds, boundsPath, "x", "y", "width", "height")
+		x, xok := v.pixels(bounds, boundsPath, "x", false)
+		y, yok := v.pixels(bounds, boundsPath, "y", false)
+		width, wok := v.pixels(bounds, boundsPath, "width", true)
+		height, hok := v.pixels(bounds, boundsPath, "height", true)
+		if limit == nil || !(xok && yok && wok && hok) {
+			continue
+		}
+		if x+width > limit.Width || y+height > limit.Height {
+			v.fail(boundsPath, "(%d,%d)-(%d,%d) is out of the %dx%d menu", x, y, x+width, y+height, limit.Width, limit.Height)
+		}
+	}
+
+	if len(v.errs) > 0 {
+		return fmt.Errorf("invalid rich menu\n  %s", strings.Join(v.errs.messages(), "\n  "))
+	}
+	return nil
+}
+
+// Create a rich menu. The response includes richmenuId.
+func (c *Client) CreateRichmenu(ctx context.Context, botID string, menu map[string]interface{}) (map[string]interface{}, error) {
+	created := map[string]interface{}{}
+	err := c.Request(ctx, http.MethodPost, RichmenuPath(botID), nil, menu, &created)
+	return created, err
+}
+
+// Get a rich menu
+func (c *Client) GetRichmenu(ctx context.Context, botID string, richmenuID string) (map[string]interface{}, error) {
+	menu := map[string]interface{}{}
+	err := c.Request(ctx, http.MethodGet, RichmenuPath(botID, richmenuID), nil, nil, &menu)
+	return menu, err
+}
+
+// Delete a rich menu
+func (c *Client) DeleteRichmenu(ctx context.Context, botID string, richmenuID string) error {
+	return c.Request(ctx, http.MethodDelete, RichmenuPath(botID, richmenuID), nil, nil, nil)
+}
+
+// Set the uploaded file as the image of a rich menu
+func (c *Client) SetRichmenuImage(ctx context.Context, botID string, richmenuID string, fileID string) error {
+	body := map[string]string{"fileId": fileID}
+	return c.Request(ctx, http.MethodPost, RichmenuPath(botID, richmenuID, "image"), nil, body, nil)
+}
+
+// Set the rich menu shown to users without their own rich menu
+func (c *Client) SetDefaultRichmenu(ctx context.Context, botID string, richmenuID string) error {
+	body := map[string]string{"defaultRichmenuId": richmenuID}
+	return c.Request(ctx, http.MethodPatch, "bots/"+url.PathEscape(botID), nil, body, nil)
+}
+
+// Set the rich menu of a user
+func (c *Client) SetUserRichmenu(ctx context.Context, botID string, richmenuID string, userID string) error {
+	return c.Request(ctx, http.MethodPost, RichmenuPath(botID, richmenuID, "users", userID), nil, nil, nil)
+}
+
+// Remove the rich menu of a user. The default rich menu is shown to the user.
+func (c *Client) DeleteUserRichmenu(ctx context.Context, botID string, userID string) error {
+	return c.Request(ctx, http.MethodDelete, RichmenuPath(botID, "users", userID), nil, nil, nil)
+}
+
+// Non-negative integer in pixels. Returns false if it is not set or invalid.
+func (v *contentValidator) pixels(obj map[string]interface{}, path string, key string, positive bool) (int, bool) {
+	value, ok := obj[key]
+	if !ok || value == nil {
+		v.fail(path+"."+key, "required")
+		return 0, false
+	}
+	var n int
+	switch t := value.(type) {
+	case int:
+		n = t
+	case float64:
+		if t != math.Trunc(t) {
+			v.fail(path+"."+key, "must be an integer")
+			return 0, false
+		}
+		n = int(t)
+	default:
+		v.fail(path+"."+key, "must be an integer")
+		return 0, false
+	}
+	if positive && n <= 0 {
+		v.fail(path+"."+key, "must be positive")
+		return 0, false
+	}
+	if n < 0 {
+		v.fail(path+"."+key, "must be 0 or more")
+		return 0, false
+	}
+	return n, true
+}
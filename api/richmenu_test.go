@@ -0,0 +1,85 @@
+package api
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestValidateRichmenu(t *testing.T) {
+	area := func(x, y, w, h int) string {
+		b, _ := json.Marshal(map[string]interface{}{
+			"bounds": map[string]int{"x": x, "y": y, "width": w, "height": h},
+			"action": map[string]string{"type": "message", "text": "hi"},
+		})
+		return string(b)
+	}
+	menu := func(size string, areas ...string) string {
+		m := `{"richmenuName":"menu","areas":[` + strings.Join(areas, ",") + `]`
+		if size != "" {
+			m += `,"size":` + size
+		}
+		return m + "}"
+	}
+	full := `{"width":2500,"height":843}`
+
+	cases := []struct {
+		name  string
+		menu  string
+		image *ImageSize
+		// Substring of the error, or empty if valid
+		err string
+	}{
+		{"two areas", menu(full, area(0, 0, 1250, 843), area(1250, 0, 1250, 843)), nil, ""},
+		{"matching image", menu(full, area(0, 0, 2500, 843)), &ImageSize{2500, 843}, ""},
+		{"area at the edge", menu(full, area(2499, 842, 1, 1)), nil, ""},
+		{"area over the width", menu(full, area(1250, 0, 1251, 843)), nil, "richmenu.areas[0].bounds: (1250,0)-(2501,843) is out of the 2500x843 menu"},
+		{"area over the height", menu(full, area(0, 1, 100, 843)), nil, "is out of the 2500x843 menu"},
+		{"image size mismatch", menu(full, area(0, 0, 100, 100)), &ImageSize{2500, 1686}, "richmenu.size: 2500x843 does not match the image size 2500x1686"},
+		{"negative position", menu(full, area(-1, 0, 100, 100)), nil, "richmenu.areas[0].bounds.x: must be 0 or more"},
+		{"zero width", menu(full, area(0, 0, 0, 100)), nil, "richmenu.areas[0].bounds.width: must be positive"},
+		{"fractional size", menu(`{"width":2500.5,"height":843}`, area(0, 0, 100, 100)), nil, "richmenu.size.width: must be an integer"},
+		{"no size", menu("", area(0, 0, 100, 100)), nil, "richmenu.size: required"},
+		{"no areas", menu(full), nil, "richmenu.areas: must have 1 to 20 items"},
+		{"too many areas", menu(full, strings.Repeat(area(0, 0, 1, 1)+",", MAX_RICHMENU_AREAS)+area(0, 0, 1, 1)), nil, "richmenu.areas: must have 1 to 20 items, but has 21"},
+		{"no name", `{"size":` + full + `,"areas":[` + area(0, 0, 1, 1) + `]}`, nil, "richmenu.richmenuName: required"},
+		{"unknown area field", `{"richmenuName":"m","size":` + full + `,"areas":[{"bounds":{"x":0,"y":0,"width":1,"height":1},"action":{"type":"message","text":"a"},"color":"red"}]}`, nil, "richmenu.areas[0].color: unknown field"},
+		{"top-level fields of API responses", `{"richmenuId":"1","richmenuName":"m","size":` + full + `,"areas":[` + area(0, 0, 1, 1) + `]}`, nil, ""},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := map[string]interface{}{}
+			if err := json.Unmarshal([]byte(c.menu), &m); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateRichmenu(m, c.image)
+			if c.err == "" {
+				if err != nil {
+					t.Errorf("ValidateRichmenu() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), c.err) {
+				t.Errorf("ValidateRichmenu() = %v, want %s", err, c.err)
+			}
+		})
+	}
+}
+
+func TestRichmenuPath(t *testing.T) {
+	cases := []struct {
+		botID string
+		elem  []string
+		want  string
+	}{
+		{"2000001", nil, "bots/2000001/richmenus"},
+		{"2000001", []string{"4000001", "image"}, "bots/2000001/richmenus/4000001/image"},
+		{"a/b", []string{"users", "u@example.com"}, "bots/a%2Fb/richmenus/users/u@example.com"},
+		{"a b", []string{"x?y"}, "bots/a%20b/richmenus/x%3Fy"},
+	}
+	for _, c := range cases {
+		if got := RichmenuPath(c.botID, c.elem...); got != c.want {
+			t.Errorf("RichmenuPath(%s, %v) = %s, want %s", c.botID, c.elem, got, c.want)
+		}
+	}
+}
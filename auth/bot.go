@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/BurntSushi/toml"
+)
+
+type Bot struct {
+	BotID          string `toml:"bot_id" json:"bot_id"`
+	BotSecret      string `toml:"bot_secret" json:"bot_secret"`
+	DefaultChannel string `toml:"default_channel,omitempty" json:"default_channel,omitempty"`
+}
+
+// Named bots of a profile
+type Bots struct {
+	// Name of the bot used when no name is given
+	Default string         `toml:"default,omitempty" json:"default,omitempty"`
+	Bots    map[string]Bot `toml:"bots" json:"bots"`
+}
+
+const CONFIG_BOTS_FILE_NAME = "bots.toml"
+
+// Names of bots in sorted order
+func (bots *Bots) Names() []string {
+	names := []string{}
+	for k := range bots.Bots {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get a bot by name. If name is empty, the default bot, or the only bot is returned.
+func (bots *Bots) Get(name string) (*Bot, error) {
+	if name == "" {
+		name = bots.Default
+	}
+	if name == "" {
+		if len(bots.Bots) != 1 {
+			return nil, fmt.Errorf("bot name is required, or set the default bot by 'lineworks configure set-bot --default'")
+		}
+		name = bots.Names()[0]
+	}
+	bot, ok := bots.Bots[name]
+	if !ok {
+		return nil, fmt.Errorf("bot '%s' does not exist", name)
+	}
+	return &bot, nil
+}
+
+func (bots Bots) ReadConfig(profile string) (*Bots, error) {
+	configFile := getConfigFileName(profile, CONFIG_BOTS_FILE_NAME)
+	_, err := os.Stat(configFile)
+	if err != nil {
+		return nil, err
+	}
+	fp, err := os.Open(configFile)
+	defer fp.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	newBots := Bots{}
+	_, err = toml.NewDecoder(fp).Decode(&newBots)
+	if newBots.Bots == nil {
+		newBots.Bots = map[string]Bot{}
+	}
+	return &newBots, err
+}
+
+func (bots *Bots) WriteConfig(profile string) error {
+	err := makeConfigProfileDir(profile)
+	if err != nil {
+		return err
+	}
+	// Bot secrets are stored
+	configFile := getConfigFileName(profile, CONFIG_BOTS_FILE_NAME)
+	fp, err := os.OpenFile(configFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	defer fp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = toml.NewEncoder(fp).Encode(bots)
+	return err
+}
@@ -0,0 +1,122 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/mmclsntr/lineworks-cli/httpclient"
+)
+
+// Tokens cached per profile, keyed by normalized scope set
+type TokenCache struct {
+	Tokens map[string]Token `toml:"tokens" json:"tokens"`
+}
+
+const CONFIG_TOKEN_CACHE_FILE_NAME = "token_cache.toml"
+
+// Margin before expiration within which a cached token is not used
+const TOKEN_EXPIRY_MARGIN = time.Minute
+
+// Find a valid token which covers the scopes
+func (cache *TokenCache) Find(scopes string) *Token {
+	// Exact scope set first
+	if t, ok := cache.Tokens[NormalizeScopes(scopes)]; ok && t.IsValid(TOKEN_EXPIRY_MARGIN) {
+		return &t
+	}
+
+	var found *Token
+	for _, t := range cache.Tokens {
+		t := t
+		if !t.IsValid(TOKEN_EXPIRY_MARGIN) || !t.Covers(scopes) {
+			continue
+		}
+		// Prefer the token with the fewest scopes
+		if found == nil || len(SplitScopes(t.Scopes)) < len(SplitScopes(found.Scopes)) {
+			found = &t
+		}
+	}
+	return found
+}
+
+// Add the token to the cache. Expired tokens are removed.
+func (cache *TokenCache) Put(token Token) {
+	if cache.Tokens == nil {
+		cache.Tokens = map[string]Token{}
+	}
+	for k, t := range cache.Tokens {
+		if !t.IsValid(0) && t.RefreshToken == "" {
+			delete(cache.Tokens, k)
+		}
+	}
+
+	key := NormalizeScopes(token.Scopes)
+	if key == "" {
+		key = NormalizeScopes(token.RequestedScopes)
+	}
+	cache.Tokens[key] = token
+}
+
+var saveTokenMutex sync.Mutex
+
+// Token which has the redaction placeholder (ex. replayed from a cassette)
+var ErrRedactedToken = errors.New("token is redacted and can not be saved")
+
+// Save token as the latest one of the profile and add it to the token cache
+func SaveToken(profile string, token *Token) error {
+	if token.AccessToken == httpclient.REDACTED || token.RefreshToken == httpclient.REDACTED {
+		return ErrRedactedToken
+	}
+
+	saveTokenMutex.Lock()
+	defer saveTokenMutex.Unlock()
+
+	if err := token.WriteConfig(profile); err != nil {
+		return err
+	}
+
+	cache, err := TokenCache{}.ReadConfig(profile)
+	if os.IsNotExist(err) {
+		cache = &TokenCache{}
+	} else if err != nil {
+		return err
+	}
+	cache.Put(*token)
+	return cache.WriteConfig(profile)
+}
+
+func (cache TokenCache) ReadConfig(profile string) (*TokenCache, error) {
+	configFile := getConfigFileName(profile, CONFIG_TOKEN_CACHE_FILE_NAME)
+	_, err := os.Stat(configFile)
+	if err != nil {
+		return nil, err
+	}
+	fp, err := os.Open(configFile)
+	defer fp.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	newCache := TokenCache{}
+	_, err = toml.NewDecoder(fp).Decode(&newCache)
+	return &newCache, err
+}
+
+func (cache *TokenCache) WriteConfig(profile string) error {
+	err := makeConfigProfileDir(profile)
+	if err != nil {
+		return err
+	}
+	configFile := getConfigFileName(profile, CONFIG_TOKEN_CACHE_FILE_NAME)
+	fp, err := os.OpenFile(configFile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	defer fp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = toml.NewEncoder(fp).Encode(cache)
+	return err
+}
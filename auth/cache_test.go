@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"testing"
+	"time"
+
+	"github.com/mmclsntr/lineworks-cli/httpclient"
+)
+
+func TestTokenCacheFind(t *testing.T) {
+	future := time.Now().Add(time.Hour)
+	cache := &TokenCache{}
+	cache.Put(Token{AccessToken: "bot", Scopes: "bot", ExpiresAt: future})
+	cache.Put(Token{AccessToken: "wide", Scopes: "bot user.read directory", ExpiresAt: future})
+	cache.Put(Token{AccessToken: "narrow", Scopes: "user.read bot", ExpiresAt: future})
+	cache.Put(Token{AccessToken: "expired", Scopes: "calendar", ExpiresAt: time.Now().Add(30 * time.Second), RefreshToken: "r"})
+
+	cases := []struct {
+		scopes string
+		want   string
+	}{
+		{"bot", "bot"},
+		{"bot user.read", "narrow"},
+		{"user.read", "narrow"},
+		{"directory", "wide"},
+		// Expires within the margin
+		{"calendar", ""},
+		{"mail", ""},
+	}
+	for _, c := range cases {
+		got := ""
+		if token := cache.Find(c.scopes); token != nil {
+			got = token.AccessToken
+		}
+		if got != c.want {
+			t.Errorf("Find(%s) = %q, want %q", c.scopes, got, c.want)
+		}
+	}
+}
+
+func TestTokenCachePut(t *testing.T) {
+	cache := &TokenCache{}
+	cache.Put(Token{AccessToken: "old", Scopes: "bot", ExpiresAt: time.Now().Add(-time.Hour)})
+	cache.Put(Token{AccessToken: "refreshable", Scopes: "calendar", ExpiresAt: time.Now().Add(-time.Hour), RefreshToken: "r"})
+	cache.Put(Token{AccessToken: "new", Scopes: "user.read", RequestedScopes: "user.read"})
+	cache.Put(Token{AccessToken: "requested", RequestedScopes: "mail bot"})
+
+	if _, ok := cache.Tokens[NormalizeScopes("bot")]; ok {
+		t.Error("expired token without refresh token is kept")
+	}
+	if _, ok := cache.Tokens[NormalizeScopes("calendar")]; !ok {
+		t.Error("expired token with refresh token is removed")
+	}
+	if token := cache.Tokens[NormalizeScopes("bot mail")]; token.AccessToken != "requested" {
+		t.Error("token without granted scopes is not keyed by requested scopes")
+	}
+}
+
+func TestSaveToken(t *testing.T) {
+	cases := []struct {
+		name  string
+		token Token
+		err   error
+		saved bool
+	}{
+		{"token", Token{AccessToken: "a", RefreshToken: "r", Scopes: "bot"}, nil, true},
+		{"redacted access token", Token{AccessToken: httpclient.REDACTED, Scopes: "bot"}, ErrRedactedToken, false},
+		{"redacted refresh token", Token{AccessToken: "a", RefreshToken: httpclient.REDACTED, Scopes: "bot"}, ErrRedactedToken, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv(CONFIG_PATH_ENV_NAME, t.TempDir())
+
+			if err := SaveToken("p", &c.token); err != c.err {
+				t.Fatalf("SaveToken() = %v, want %v", err, c.err)
+			}
+			saved, err := Token{}.ReadConfig("p")
+			if (err == nil) != c.saved {
+				t.Fatalf("saved = %v, want %v", err == nil, c.saved)
+			}
+			if !c.saved {
+				return
+			}
+			if saved.AccessToken != c.token.AccessToken {
+				t.Errorf("saved token = %s", saved.AccessToken)
+			}
+			cache, err := TokenCache{}.ReadConfig("p")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if cache.Find("bot") == nil {
+				t.Error("token is not cached")
+			}
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"github.com/BurntSushi/toml"
 	"github.com/golang-jwt/jwt/v4"
@@ -9,6 +10,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 )
 
@@ -32,6 +34,11 @@ type Token struct {
 	RefreshToken string `toml:"refresh_token" json:"refresh_token"`
 	Scopes       string `toml:"scopes" json:"scopes"`
 	ExpiredIn    string `toml:"expired_in" json:"expired_in"`
+	// Scopes requested when the token was issued
+	RequestedScopes string `toml:"requested_scopes,omitempty" json:"requested_scopes,omitempty"`
+	// Expiration time calculated from expired_in
+	ExpiresAt time.Time `toml:"expires_at,omitempty" json:"expires_at,omitempty"`
+	TokenType string    `toml:"token_type,omitempty" json:"token_type,omitempty"`
 }
 
 const CONFIG_DIR_NAME = ".config"
@@ -43,7 +50,7 @@ const CONFIG_PATH_ENV_NAME = "LINEWORKS_CONFIG_DIR"
 
 // Generate Authorization Code URL
 func (cred *ClientCredential) AuthCodeURL(state string) string {
-	u, err := url.Parse(AuthURL)
+	u, err := url.Parse(GetAuthURL())
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -70,6 +77,15 @@ func (cred *ClientCredential) AuthCodeURL(state string) string {
 
 // Get access token
 func (cred *ClientCredential) GetAccessToken(code string) Token {
+	token, err := cred.FetchAccessToken(code)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return token
+}
+
+// Get access token. Returns error instead of exiting.
+func (cred *ClientCredential) FetchAccessToken(code string) (Token, error) {
 	// Create request body
 	req := AccessTokenRequestBody{
 		Code:         code,
@@ -82,21 +98,28 @@ func (cred *ClientCredential) GetAccessToken(code string) Token {
 	// Request
 	res_body, err := RequestAccessToken(req)
 	if err != nil {
-		log.Fatal(err)
+		return Token{}, err
 	}
 
-	return Token{
-		AccessToken:  res_body.AccessToken,
-		RefreshToken: res_body.RefreshToken,
-		Scopes:       res_body.Scopes,
-		ExpiredIn:    res_body.ExpiredIn,
-	}
+	return newToken(res_body, cred.Scopes), nil
 }
 
 // Get access token (JWT)
 func (cred *ClientCredential) GetAccessTokenJWT(sva ServiceAccount) Token {
+	token, err := cred.FetchAccessTokenJWT(sva)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return token
+}
+
+// Get access token (JWT). Returns error instead of exiting.
+func (cred *ClientCredential) FetchAccessTokenJWT(sva ServiceAccount) (Token, error) {
 	// JWT
-	jwt := GenerateJWT(cred.ClientID, sva.ServiceAccountID, sva.PrivateKey)
+	jwt, err := generateJWT(cred.ClientID, sva.ServiceAccountID, sva.PrivateKey)
+	if err != nil {
+		return Token{}, err
+	}
 
 	// Create request body
 	req := AccessTokenJWTRequestBody{
@@ -110,23 +133,94 @@ func (cred *ClientCredential) GetAccessTokenJWT(sva ServiceAccount) Token {
 	// Request
 	res_body, err := RequestAccessTokenJWT(req)
 	if err != nil {
-		log.Fatal(err)
+		return Token{}, err
 	}
 
-	return Token{
+	return newToken(res_body, cred.Scopes), nil
+}
+
+func newToken(res_body AccessTokenResponseBody, requestedScopes string) Token {
+	token := Token{
 		AccessToken:  res_body.AccessToken,
 		RefreshToken: res_body.RefreshToken,
 		Scopes:       res_body.Scopes,
 		ExpiredIn:    res_body.ExpiredIn,
+		TokenType:    res_body.TokenType,
+
+		RequestedScopes: requestedScopes,
+	}
+	if sec, err := strconv.Atoi(res_body.ExpiredIn); err == nil {
+		token.ExpiresAt = time.Now().Add(time.Duration(sec) * time.Second).Truncate(time.Second)
+	}
+	return token
+}
+
+// Check the token is not expired.
+// A token without expiration time is regarded as valid.
+func (token *Token) IsValid(margin time.Duration) bool {
+	if token.AccessToken == "" {
+		return false
+	}
+	if token.ExpiresAt.IsZero() {
+		return true
+	}
+	return time.Now().Add(margin).Before(token.ExpiresAt)
+}
+
+// Check the token has all of the scopes
+func (token *Token) Covers(scopes string) bool {
+	granted := map[string]bool{}
+	for _, s := range SplitScopes(token.Scopes) {
+		granted[s] = true
+	}
+	for _, s := range SplitScopes(scopes) {
+		if !granted[s] {
+			return false
+		}
 	}
+	return true
 }
 
 // Refresh access token
-//func (conf Config) RefreshAccessToken(token Token) Token {
-//}
+func (cred *ClientCredential) RefreshAccessToken(token Token) (Token, error) {
+	if token.RefreshToken == "" {
+		return token, errors.New("refresh token does not exist")
+	}
+
+	// Create request body
+	req := RefreshTokenRequestBody{
+		RefreshToken: token.RefreshToken,
+		GrantType:    "refresh_token",
+		ClientID:     cred.ClientID,
+		ClientSecret: cred.ClientSecret,
+	}
+
+	// Request
+	res_body, err := RequestRefreshAccessToken(req)
+	if err != nil {
+		return token, err
+	}
+
+	refreshed := newToken(AccessTokenResponseBody{
+		AccessToken:  res_body.AccessToken,
+		RefreshToken: token.RefreshToken,
+		Scopes:       res_body.Scopes,
+		ExpiredIn:    res_body.ExpiredIn,
+		TokenType:    res_body.TokenType,
+	}, token.RequestedScopes)
+	return refreshed, nil
+}
 
 // Generate JWT
 func GenerateJWT(clientId string, serviceAccountId string, privateKey string) string {
+	tokenString, err := generateJWT(clientId, serviceAccountId, privateKey)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return tokenString
+}
+
+func generateJWT(clientId string, serviceAccountId string, privateKey string) (string, error) {
 	currentTime := time.Now()
 	// Claims object
 	claims := jwt.MapClaims{
@@ -140,15 +234,11 @@ func GenerateJWT(clientId string, serviceAccountId string, privateKey string) st
 	// Key
 	key, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(privateKey))
 	if err != nil {
-		log.Fatal(err)
+		return "", err
 	}
 
 	// Sign
-	tokenString, err := token.SignedString(key)
-	if err != nil {
-		log.Fatal(err)
-	}
-	return tokenString
+	return token.SignedString(key)
 }
 
 // Get Redirect URL
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/mmclsntr/lineworks-cli/httpclient"
+)
+
+const CONFIG_HTTP_FILE_NAME = "http.toml"
+
+// Read HTTP client settings of the profile
+func ReadHTTPConfig(profile string) (*httpclient.Config, error) {
+	return readHTTPConfigFile(getConfigFileName(profile, CONFIG_HTTP_FILE_NAME))
+}
+
+// Write HTTP client settings of the profile
+func WriteHTTPConfig(profile string, conf *httpclient.Config) error {
+	err := makeConfigProfileDir(profile)
+	if err != nil {
+		return err
+	}
+	configFile := getConfigFileName(profile, CONFIG_HTTP_FILE_NAME)
+	fp, err := os.Create(configFile)
+	defer fp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = toml.NewEncoder(fp).Encode(conf)
+	return err
+}
+
+// Read global HTTP client settings, which are stored in the config base dir
+func ReadGlobalHTTPConfig() (*httpclient.Config, error) {
+	return readHTTPConfigFile(filepath.Join(getConfigBasePath(), CONFIG_HTTP_FILE_NAME))
+}
+
+// Load HTTP client settings. Profile settings take precedence over global ones.
+// Missing files are ignored.
+func LoadHTTPConfig(profile string) (httpclient.Config, error) {
+	conf := httpclient.Config{}
+
+	global, err := ReadGlobalHTTPConfig()
+	if err != nil && !os.IsNotExist(err) {
+		return conf, err
+	}
+	if global != nil {
+		conf = conf.Merge(*global)
+	}
+
+	if profile != "" {
+		p, err := ReadHTTPConfig(profile)
+		if err != nil && !os.IsNotExist(err) {
+			return conf, err
+		}
+		if p != nil {
+			conf = conf.Merge(*p)
+		}
+	}
+	return conf, nil
+}
+
+func readHTTPConfigFile(configFile string) (*httpclient.Config, error) {
+	_, err := os.Stat(configFile)
+	if err != nil {
+		return nil, err
+	}
+	fp, err := os.Open(configFile)
+	defer fp.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	conf := httpclient.Config{}
+	_, err = toml.NewDecoder(fp).Decode(&conf)
+	return &conf, err
+}
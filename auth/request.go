@@ -7,11 +7,48 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"os"
+	"strings"
 )
 
 const AuthURL = "https://auth.worksmobile.com/oauth2/v2.0/authorize"
 const TokenURL = "https://auth.worksmobile.com/oauth2/v2.0/token"
 
+// Environment variable to override the base URL of auth endpoints (ex. http://127.0.0.1:9080 for mock server)
+const AUTH_BASE_URL_ENV_NAME = "LINEWORKS_AUTH_BASE_URL"
+
+// Get Authorization URL. It can be overridden by LINEWORKS_AUTH_BASE_URL.
+func GetAuthURL() string {
+	if base := os.Getenv(AUTH_BASE_URL_ENV_NAME); base != "" {
+		return strings.TrimSuffix(base, "/") + "/oauth2/v2.0/authorize"
+	}
+	return AuthURL
+}
+
+// Get Token URL. It can be overridden by LINEWORKS_AUTH_BASE_URL.
+func GetTokenURL() string {
+	if base := os.Getenv(AUTH_BASE_URL_ENV_NAME); base != "" {
+		return strings.TrimSuffix(base, "/") + "/oauth2/v2.0/token"
+	}
+	return TokenURL
+}
+
+// HTTP client used for token requests
+var httpClient = http.DefaultClient
+
+// Set HTTP client used for token requests
+func SetHTTPClient(client *http.Client) {
+	httpClient = client
+}
+
+// Get HTTP client used for token requests
+func HTTPClient() *http.Client {
+	return httpClient
+}
+
+// Access token used in replay mode instead of the stored one
+const REPLAY_ACCESS_TOKEN = "replay"
+
 type AccessTokenRequestBody struct {
 	Code         string `json:"code"`
 	GrantType    string `json:"grant_type"`
@@ -65,8 +102,6 @@ func RequestAccessTokenJWT(req_body AccessTokenJWTRequestBody) (AccessTokenRespo
 }
 
 func requestAccessToken(req_body_json []byte) (AccessTokenResponseBody, error) {
-	//log.Printf("%s", req_body_json)
-
 	res_body := AccessTokenResponseBody{}
 
 	mapData := map[string]string{}
@@ -79,10 +114,12 @@ func requestAccessToken(req_body_json []byte) (AccessTokenResponseBody, error) {
 	}
 
 	// Request
-	res, _ := http.PostForm(TokenURL, req_body_data)
+	res, err := httpClient.PostForm(GetTokenURL(), req_body_data)
+	if err != nil {
+		return res_body, err
+	}
 	defer res.Body.Close()
 
-	//log.Printf("%v", res)
 	body, _ := io.ReadAll(res.Body)
 
 	if res.StatusCode != 200 {
@@ -97,5 +134,18 @@ func requestAccessToken(req_body_json []byte) (AccessTokenResponseBody, error) {
 }
 
 // Refresh AccessToken
-//func RequestRefreshAccessToken(req_body RefreshTokenRequestBody) (RefreshTokenResponseBody, error) {
-//}
+func RequestRefreshAccessToken(req_body RefreshTokenRequestBody) (RefreshTokenResponseBody, error) {
+	req_body_json, _ := json.Marshal(req_body)
+
+	res, err := requestAccessToken(req_body_json)
+	if err != nil {
+		return RefreshTokenResponseBody{}, err
+	}
+
+	return RefreshTokenResponseBody{
+		AccessToken: res.AccessToken,
+		Scopes:      res.Scopes,
+		ExpiredIn:   res.ExpiredIn,
+		TokenType:   res.TokenType,
+	}, nil
+}
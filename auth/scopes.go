@@ -0,0 +1,222 @@
+package auth
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Known LINE WORKS API scopes
+var KnownScopes = []string{
+	"audit.read",
+	"board",
+	"board.read",
+	"bot",
+	"bot.message",
+	"bot.read",
+	"calendar",
+	"calendar.read",
+	"contact",
+	"contact.read",
+	"directory",
+	"directory.read",
+	"file",
+	"file.read",
+	"group",
+	"group.folder",
+	"group.note",
+	"group.note.read",
+	"group.read",
+	"mail",
+	"mail.read",
+	"orgunit",
+	"orgunit.read",
+	"partner",
+	"partner.read",
+	"security.external.read",
+	"task",
+	"task.read",
+	"user",
+	"user.email.read",
+	"user.profile.read",
+	"user.profile.write",
+	"user.read",
+}
+
+// Built-in scope presets
+var BuiltinScopePresets = map[string]string{
+	"bot":            "bot,bot.read",
+	"bot-admin":      "bot,bot.read,bot.message,user.read",
+	"directory-read": "directory.read,user.read,orgunit.read,group.read",
+	"directory":      "directory,user,orgunit,group",
+	"calendar":       "calendar,calendar.read",
+	"mail":           "mail,mail.read",
+}
+
+type ScopePresets struct {
+	Presets map[string]string `toml:"presets" json:"presets"`
+}
+
+const CONFIG_SCOPE_PRESETS_FILE_NAME = "scope_presets.toml"
+
+// Split comma-delimited scopes into a sorted, de-duplicated list
+func SplitScopes(scopes string) []string {
+	seen := map[string]bool{}
+	list := []string{}
+	for _, s := range strings.FieldsFunc(scopes, func(r rune) bool { return r == ',' || r == ' ' }) {
+		if s == "" || seen[s] {
+			continue
+		}
+		seen[s] = true
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Normalize comma-delimited scopes
+func NormalizeScopes(scopes string) string {
+	return strings.Join(SplitScopes(scopes), ",")
+}
+
+// Validate scopes against the known scope catalog
+func ValidateScopes(scopes string) error {
+	known := map[string]bool{}
+	for _, s := range KnownScopes {
+		known[s] = true
+	}
+
+	unknown := []string{}
+	for _, s := range SplitScopes(scopes) {
+		if known[s] {
+			continue
+		}
+		if suggestion := suggestScope(s); suggestion != "" {
+			unknown = append(unknown, fmt.Sprintf("'%s' (did you mean '%s'?)", s, suggestion))
+		} else {
+			unknown = append(unknown, fmt.Sprintf("'%s'", s))
+		}
+	}
+	if len(unknown) > 0 {
+		return fmt.Errorf("unknown scopes: %s", strings.Join(unknown, ", "))
+	}
+	return nil
+}
+
+// Compare requested and granted scopes.
+// Returns scopes which were requested but not granted, and scopes which were granted but not requested.
+func DiffScopes(requested string, granted string) (missing []string, extra []string) {
+	grantedSet := map[string]bool{}
+	for _, s := range SplitScopes(granted) {
+		grantedSet[s] = true
+	}
+	requestedSet := map[string]bool{}
+	for _, s := range SplitScopes(requested) {
+		requestedSet[s] = true
+		if !grantedSet[s] {
+			missing = append(missing, s)
+		}
+	}
+	for _, s := range SplitScopes(granted) {
+		if !requestedSet[s] {
+			extra = append(extra, s)
+		}
+	}
+	return missing, extra
+}
+
+// Resolve a scope preset name. Presets in the profile take precedence over built-in ones.
+func ResolveScopePreset(profile string, name string) (string, error) {
+	presets, err := ScopePresets{}.ReadConfig(profile)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	if presets != nil {
+		if scopes, ok := presets.Presets[name]; ok {
+			return scopes, nil
+		}
+	}
+	if scopes, ok := BuiltinScopePresets[name]; ok {
+		return scopes, nil
+	}
+	return "", fmt.Errorf("scope preset '%s' does not exist", name)
+}
+
+func suggestScope(scope string) string {
+	best := ""
+	bestDist := 3
+	for _, s := range KnownScopes {
+		if d := levenshtein(scope, s); d < bestDist {
+			best = s
+			bestDist = d
+		}
+	}
+	return best
+}
+
+func levenshtein(a string, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min3(a int, b int, c int) int {
+	m := a
+	if b < m {
+		m = b
+	}
+	if c < m {
+		m = c
+	}
+	return m
+}
+
+func (presets ScopePresets) ReadConfig(profile string) (*ScopePresets, error) {
+	configFile := getConfigFileName(profile, CONFIG_SCOPE_PRESETS_FILE_NAME)
+	_, err := os.Stat(configFile)
+	if err != nil {
+		return nil, err
+	}
+	fp, err := os.Open(configFile)
+	defer fp.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	newPresets := ScopePresets{}
+	_, err = toml.NewDecoder(fp).Decode(&newPresets)
+	return &newPresets, err
+}
+
+func (presets *ScopePresets) WriteConfig(profile string) error {
+	err := makeConfigProfileDir(profile)
+	if err != nil {
+		return err
+	}
+	configFile := getConfigFileName(profile, CONFIG_SCOPE_PRESETS_FILE_NAME)
+	fp, err := os.Create(configFile)
+	defer fp.Close()
+	if err != nil {
+		return err
+	}
+
+	err = toml.NewEncoder(fp).Encode(presets)
+	return err
+}
@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestValidateScopes(t *testing.T) {
+	cases := []struct {
+		scopes string
+		err    string
+	}{
+		{"bot", ""},
+		{"bot,user.read, directory", ""},
+		{"", ""},
+		{"bto", "unknown scopes: 'bto' (did you mean 'bot'?)"},
+		{"bot,unknown.scope.name", "unknown scopes: 'unknown.scope.name'"},
+		{"bto,user.raed", "unknown scopes: 'bto' (did you mean 'bot'?), 'user.raed' (did you mean 'user.read'?)"},
+	}
+	for _, c := range cases {
+		err := ValidateScopes(c.scopes)
+		if c.err == "" {
+			if err != nil {
+				t.Errorf("ValidateScopes(%q) = %v, want nil", c.scopes, err)
+			}
+			continue
+		}
+		if err == nil || err.Error() != c.err {
+			t.Errorf("ValidateScopes(%q) = %v, want %s", c.scopes, err, c.err)
+		}
+	}
+}
+
+func TestSuggestScope(t *testing.T) {
+	cases := []struct {
+		scope string
+		want  string
+	}{
+		{"bto", "bot"},
+		{"calender", "calendar"},
+		{"user.raed", "user.read"},
+		{"bot", "bot"},
+		{"something", ""},
+	}
+	for _, c := range cases {
+		if got := suggestScope(c.scope); got != c.want {
+			t.Errorf("suggestScope(%q) = %q, want %q", c.scope, got, c.want)
+		}
+	}
+}
+
+func TestDiffScopes(t *testing.T) {
+	cases := []struct {
+		name      string
+		requested string
+		granted   string
+		missing   []string
+		extra     []string
+	}{
+		{"same", "bot,user.read", "user.read,bot", nil, nil},
+		{"not granted", "bot,user.read", "bot", []string{"user.read"}, nil},
+		{"not requested", "bot", "bot bot.read", nil, []string{"bot.read"}},
+		{"both", "bot,mail", "bot,calendar", []string{"mail"}, []string{"calendar"}},
+		{"nothing requested", "", "bot", nil, []string{"bot"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			missing, extra := DiffScopes(c.requested, c.granted)
+			if !reflect.DeepEqual(missing, c.missing) || !reflect.DeepEqual(extra, c.extra) {
+				t.Errorf("DiffScopes() = %v, %v, want %v, %v", missing, extra, c.missing, c.extra)
+			}
+		})
+	}
+}
+
+func TestResolveScopePreset(t *testing.T) {
+	t.Setenv(CONFIG_PATH_ENV_NAME, t.TempDir())
+	presets := &ScopePresets{Presets: map[string]string{"bot": "bot", "mine": "mail.read"}}
+	if err := presets.WriteConfig("p"); err != nil {
+		t.Fatal(err)
+	}
+	cases := []struct {
+		name string
+		want string
+		err  bool
+	}{
+		{"mine", "mail.read", false},
+		{"bot", "bot", false},
+		{"calendar", BuiltinScopePresets["calendar"], false},
+		{"none", "", true},
+	}
+	for _, c := range cases {
+		got, err := ResolveScopePreset("p", c.name)
+		if (err != nil) != c.err || got != c.want {
+			t.Errorf("ResolveScopePreset(%q) = %q, %v, want %q", c.name, got, err, c.want)
+		}
+		if err != nil && !strings.Contains(err.Error(), c.name) {
+			t.Errorf("error %q does not name the preset", err)
+		}
+	}
+}
@@ -0,0 +1,202 @@
+package auth
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2"
+
+	"github.com/mmclsntr/lineworks-cli/httpclient"
+)
+
+// Returned when no valid token is available and it can not be obtained without user interaction
+var ErrTokenUnavailable = errors.New("no valid access token is available. Authorize again by 'lineworks auth user-account'")
+
+// TokenSource caches an access token and renews it before expiry.
+// It implements golang.org/x/oauth2.TokenSource and is safe for concurrent use.
+type TokenSource struct {
+	// Renew the token this long before expiry. Must be set before first use.
+	RenewBefore time.Duration
+
+	mu    sync.Mutex
+	token *Token
+	load  func() (*Token, error)
+	renew func(current *Token) (*Token, error)
+}
+
+var _ oauth2.TokenSource = (*TokenSource)(nil)
+
+// Create TokenSource which gets tokens by Service Account authorization
+func NewServiceAccountTokenSource(cred ClientCredential, sa ServiceAccount) *TokenSource {
+	return &TokenSource{
+		RenewBefore: TOKEN_EXPIRY_MARGIN,
+		renew: func(current *Token) (*Token, error) {
+			token, err := cred.FetchAccessTokenJWT(sa)
+			if err != nil {
+				return nil, err
+			}
+			return &token, nil
+		},
+	}
+}
+
+// Create TokenSource which always returns the token. It can not be renewed.
+func NewStaticTokenSource(token Token) *TokenSource {
+	return &TokenSource{
+		token: &token,
+		renew: func(current *Token) (*Token, error) {
+			if current != nil {
+				return current, nil
+			}
+			return nil, ErrTokenUnavailable
+		},
+	}
+}
+
+// Create TokenSource from a stored profile.
+// If scopes are given, tokens which cover them are used. Renewed tokens are stored in the profile.
+func NewProfileTokenSource(profile string, scopes string) (*TokenSource, error) {
+	cred, err := ClientCredential{}.ReadConfig(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenSource{
+		RenewBefore: TOKEN_EXPIRY_MARGIN,
+		load: func() (*Token, error) {
+			return loadProfileToken(profile, scopes)
+		},
+		renew: func(current *Token) (*Token, error) {
+			return renewProfileToken(profile, cred, scopes, current)
+		},
+	}, nil
+}
+
+// Get a valid token in oauth2 format
+func (ts *TokenSource) Token() (*oauth2.Token, error) {
+	token, err := ts.AccessToken()
+	if err != nil {
+		return nil, err
+	}
+
+	tokenType := token.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+	return &oauth2.Token{
+		AccessToken:  token.AccessToken,
+		TokenType:    tokenType,
+		RefreshToken: token.RefreshToken,
+		Expiry:       token.ExpiresAt,
+	}, nil
+}
+
+// Get a valid token. It is renewed if it expires within RenewBefore.
+func (ts *TokenSource) AccessToken() (*Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if ts.token == nil && ts.load != nil {
+		token, err := ts.load()
+		if err != nil {
+			return nil, err
+		}
+		ts.token = token
+	}
+	if ts.token != nil && ts.token.IsValid(ts.RenewBefore) {
+		return ts.token, nil
+	}
+	return ts.renewLocked()
+}
+
+// Renew the token regardless of its expiration time
+func (ts *TokenSource) Renew() (*Token, error) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	return ts.renewLocked()
+}
+
+func (ts *TokenSource) renewLocked() (*Token, error) {
+	token, err := ts.renew(ts.token)
+	if err != nil {
+		return nil, err
+	}
+	ts.token = token
+	return token, nil
+}
+
+// Load a token from the profile. Returns nil if no token is stored.
+func loadProfileToken(profile string, scopes string) (*Token, error) {
+	if scopes == "" {
+		token, err := Token{}.ReadConfig(profile)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return token, err
+	}
+
+	cache, err := TokenCache{}.ReadConfig(profile)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return cache.Find(scopes), nil
+}
+
+// Renew a token of the profile by refresh token, or by Service Account if it is configured
+func renewProfileToken(profile string, cred *ClientCredential, scopes string, current *Token) (*Token, error) {
+	// Refresh tokens which cover the scopes
+	candidates := []Token{}
+	if current != nil && current.RefreshToken != "" {
+		candidates = append(candidates, *current)
+	}
+	if scopes != "" {
+		if cache, err := (TokenCache{}).ReadConfig(profile); err == nil {
+			for _, t := range cache.Tokens {
+				if t.RefreshToken != "" && t.Covers(scopes) {
+					candidates = append(candidates, t)
+				}
+			}
+		}
+	}
+	for _, t := range candidates {
+		refreshed, err := cred.RefreshAccessToken(t)
+		if errors.Is(err, httpclient.ErrDryRun) {
+			return nil, err
+		} else if err != nil {
+			continue
+		}
+		if err := SaveToken(profile, &refreshed); err != nil {
+			return nil, err
+		}
+		return &refreshed, nil
+	}
+
+	sa, err := ServiceAccount{}.ReadConfig(profile)
+	if os.IsNotExist(err) {
+		return nil, ErrTokenUnavailable
+	} else if err != nil {
+		return nil, err
+	}
+
+	saCred := *cred
+	if scopes != "" {
+		saCred.Scopes = NormalizeScopes(scopes)
+	} else if current != nil && current.RequestedScopes != "" {
+		saCred.Scopes = current.RequestedScopes
+	} else if current != nil && current.Scopes != "" {
+		saCred.Scopes = current.Scopes
+	}
+	token, err := saCred.FetchAccessTokenJWT(*sa)
+	if err != nil {
+		return nil, err
+	}
+	if err := SaveToken(profile, &token); err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
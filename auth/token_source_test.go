@@ -0,0 +1,152 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Token endpoint which refreshes the refresh token "good" and mints tokens for JWT assertions signed by key
+func newTestTokenServer(t *testing.T, key *rsa.PublicKey, grants *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Error(err)
+		}
+		grant := r.PostForm.Get("grant_type")
+		*grants = append(*grants, grant)
+		res := AccessTokenResponseBody{ExpiredIn: "86400", TokenType: "Bearer"}
+		switch grant {
+		case "refresh_token":
+			if r.PostForm.Get("refresh_token") != "good" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			res.AccessToken = "refreshed"
+			res.Scopes = "bot"
+		case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+			claims := jwt.MapClaims{}
+			_, err := jwt.ParseWithClaims(r.PostForm.Get("assertion"), claims, func(*jwt.Token) (interface{}, error) { return key, nil })
+			if err != nil || claims["iss"] != r.PostForm.Get("client_id") || claims["sub"] != "sa" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			res.AccessToken = "minted"
+			res.Scopes = r.PostForm.Get("scope")
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestProfileTokenSource(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKey := string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}))
+	valid := time.Now().Add(time.Hour)
+	expired := time.Now().Add(-time.Hour)
+
+	const jwtGrant = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	cases := []struct {
+		name           string
+		token          *Token
+		cached         []Token
+		serviceAccount bool
+		scopes         string
+		want           string
+		err            error
+		grants         []string
+	}{
+		{"valid token", &Token{AccessToken: "stored", Scopes: "bot", ExpiresAt: valid}, nil, false, "", "stored", nil, nil},
+		{"refresh expired token", &Token{AccessToken: "stored", RefreshToken: "good", Scopes: "bot", ExpiresAt: expired}, nil, false, "", "refreshed", nil,
+			[]string{"refresh_token"}},
+		{"re-mint by service account", &Token{AccessToken: "stored", Scopes: "bot", ExpiresAt: expired}, nil, true, "", "minted", nil, []string{jwtGrant}},
+		{"re-mint after failed refresh", &Token{AccessToken: "stored", RefreshToken: "revoked", Scopes: "bot", ExpiresAt: expired}, nil, true, "", "minted", nil,
+			[]string{"refresh_token", jwtGrant}},
+		{"unavailable without service account", &Token{AccessToken: "stored", RefreshToken: "revoked", Scopes: "bot", ExpiresAt: expired}, nil, false, "", "",
+			ErrTokenUnavailable, []string{"refresh_token"}},
+		{"no token", nil, nil, false, "", "", ErrTokenUnavailable, nil},
+		{"cached token of scopes", nil, []Token{{AccessToken: "cached", Scopes: "bot", ExpiresAt: valid}}, false, "bot", "cached", nil, nil},
+		{"refresh cached token of scopes", nil, []Token{{AccessToken: "cached", RefreshToken: "good", Scopes: "bot", ExpiresAt: expired}}, false, "bot", "refreshed", nil,
+			[]string{"refresh_token"}},
+		{"mint scopes not cached", nil, []Token{{AccessToken: "cached", Scopes: "bot", ExpiresAt: valid}}, true, "user.read", "minted", nil, []string{jwtGrant}},
+		{"scopes not cached without service account", nil, []Token{{AccessToken: "cached", Scopes: "bot", ExpiresAt: valid}}, false, "user.read", "",
+			ErrTokenUnavailable, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			grants := []string{}
+			srv := newTestTokenServer(t, &key.PublicKey, &grants)
+			t.Setenv(AUTH_BASE_URL_ENV_NAME, srv.URL)
+			t.Setenv(CONFIG_PATH_ENV_NAME, t.TempDir())
+
+			cred := &ClientCredential{ClientID: "id", ClientSecret: "secret", Scopes: "bot"}
+			if err := cred.WriteConfig("p"); err != nil {
+				t.Fatal(err)
+			}
+			if c.token != nil {
+				if err := c.token.WriteConfig("p"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if c.cached != nil {
+				cache := &TokenCache{}
+				for _, token := range c.cached {
+					cache.Put(token)
+				}
+				if err := cache.WriteConfig("p"); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if c.serviceAccount {
+				sa := &ServiceAccount{ServiceAccountID: "sa", PrivateKey: privateKey}
+				if err := sa.WriteConfig("p"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			ts, err := NewProfileTokenSource("p", c.scopes)
+			if err != nil {
+				t.Fatal(err)
+			}
+			token, err := ts.AccessToken()
+			if !errors.Is(err, c.err) {
+				t.Fatalf("AccessToken() = %v, want %v", err, c.err)
+			}
+			if c.err == nil && token.AccessToken != c.want {
+				t.Errorf("access token = %s, want %s", token.AccessToken, c.want)
+			}
+			if len(grants) != len(c.grants) || (len(grants) > 0 && !reflect.DeepEqual(grants, c.grants)) {
+				t.Errorf("grants = %v, want %v", grants, c.grants)
+			}
+			if len(c.grants) == 0 || c.err != nil {
+				return
+			}
+
+			// Renewed tokens are saved to the profile
+			saved, err := Token{}.ReadConfig("p")
+			if err != nil || saved.AccessToken != c.want {
+				t.Errorf("saved token = %v, %v, want %s", saved, err, c.want)
+			}
+			if !token.Covers(c.scopes) {
+				t.Errorf("token scopes = %s, want to cover %s", token.Scopes, c.scopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+)
+
+// Transport is an http.RoundTripper which injects the access token as a bearer token.
+// On 401 response, the token is renewed and the request is retried once.
+type Transport struct {
+	Source *TokenSource
+	// Base RoundTripper. http.DefaultTransport is used if nil.
+	Base http.RoundTripper
+}
+
+// Create http.Client which authenticates requests by the token source
+func NewClient(ts *TokenSource) *http.Client {
+	return &http.Client{
+		Transport: &Transport{Source: ts},
+	}
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	token, err := t.token(ctx, false)
+	if err != nil {
+		closeRequestBody(req)
+		return nil, err
+	}
+	res, err := t.base().RoundTrip(authorizedRequest(req, req.Body, token))
+	if err != nil || res.StatusCode != http.StatusUnauthorized {
+		return res, err
+	}
+
+	// Retry once with a renewed token. The request body must be re-readable.
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return res, nil
+	}
+	token, err = t.token(ctx, true)
+	if err != nil {
+		return res, nil
+	}
+	body := req.Body
+	if req.GetBody != nil {
+		body, err = req.GetBody()
+		if err != nil {
+			return res, nil
+		}
+	}
+	io.Copy(io.Discard, res.Body)
+	res.Body.Close()
+
+	return t.base().RoundTrip(authorizedRequest(req, body, token))
+}
+
+func (t *Transport) base() http.RoundTripper {
+	if t.Base != nil {
+		return t.Base
+	}
+	return http.DefaultTransport
+}
+
+// Get token, giving up when the request context is done
+func (t *Transport) token(ctx context.Context, renew bool) (*Token, error) {
+	type result struct {
+		token *Token
+		err   error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		var r result
+		if renew {
+			r.token, r.err = t.Source.Renew()
+		} else {
+			r.token, r.err = t.Source.AccessToken()
+		}
+		ch <- r
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-ch:
+		return r.token, r.err
+	}
+}
+
+func authorizedRequest(req *http.Request, body io.ReadCloser, token *Token) *http.Request {
+	tokenType := token.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+
+	newReq := req.Clone(req.Context())
+	newReq.Body = body
+	newReq.Header.Set("Authorization", tokenType+" "+token.AccessToken)
+	return newReq
+}
+
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTransport(t *testing.T) {
+	cases := []struct {
+		name string
+		// Token accepted by the server
+		accepted string
+		token    *Token
+		rewind   bool
+		status   int
+		auths    []string
+		renewals int
+		bodies   []string
+	}{
+		{"bearer token", "a", &Token{AccessToken: "a"}, true, http.StatusOK, []string{"Bearer a"}, 0, []string{"body"}},
+		{"token type", "a", &Token{AccessToken: "a", TokenType: "MAC"}, true, http.StatusOK, []string{"MAC a"}, 0, []string{"body"}},
+		{"renew on 401", "renewed", &Token{AccessToken: "revoked"}, true, http.StatusOK, []string{"Bearer revoked", "Bearer renewed"}, 1, []string{"body", "body"}},
+		{"retry only once", "none", &Token{AccessToken: "revoked"}, true, http.StatusUnauthorized, []string{"Bearer revoked", "Bearer renewed"}, 1, []string{"body", "body"}},
+		{"body can not be rewound", "renewed", &Token{AccessToken: "revoked"}, false, http.StatusUnauthorized, []string{"Bearer revoked"}, 0, []string{"body"}},
+		{"no token", "a", nil, true, 0, nil, 1, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			auths := []string{}
+			bodies := []string{}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				b, _ := io.ReadAll(r.Body)
+				auths = append(auths, r.Header.Get("Authorization"))
+				bodies = append(bodies, string(b))
+				if !strings.HasSuffix(r.Header.Get("Authorization"), " "+c.accepted) {
+					w.WriteHeader(http.StatusUnauthorized)
+				}
+			}))
+			defer srv.Close()
+
+			// Issues "renewed" if there is a token to renew
+			renewals := 0
+			ts := &TokenSource{
+				token: c.token,
+				renew: func(current *Token) (*Token, error) {
+					renewals++
+					if current == nil {
+						return nil, ErrTokenUnavailable
+					}
+					return &Token{AccessToken: "renewed"}, nil
+				},
+			}
+			var body io.Reader = strings.NewReader("body")
+			if !c.rewind {
+				// Not a known body type, so GetBody is not set
+				body = io.MultiReader(body)
+			}
+			req, err := http.NewRequest(http.MethodPost, srv.URL, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := (&Transport{Source: ts}).RoundTrip(req)
+			if c.token == nil {
+				if !errors.Is(err, ErrTokenUnavailable) {
+					t.Fatalf("RoundTrip() = %v, want ErrTokenUnavailable", err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			} else {
+				res.Body.Close()
+				if res.StatusCode != c.status {
+					t.Errorf("status = %d, want %d", res.StatusCode, c.status)
+				}
+			}
+			if strings.Join(auths, ",") != strings.Join(c.auths, ",") {
+				t.Errorf("Authorization = %v, want %v", auths, c.auths)
+			}
+			if strings.Join(bodies, ",") != strings.Join(c.bodies, ",") {
+				t.Errorf("bodies = %v, want %v", bodies, c.bodies)
+			}
+			if renewals != c.renewals {
+				t.Errorf("renewals = %d, want %d", renewals, c.renewals)
+			}
+			if req.Header.Get("Authorization") != "" {
+				t.Error("the original request is modified")
+			}
+		})
+	}
+}
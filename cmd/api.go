@@ -0,0 +1,282 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/auth"
+)
+
+// Create API client authenticated by the profile's token
+func newAPIClient(profile string, scopes string) (*api.Client, error) {
+	var ts *auth.TokenSource
+	if dryRun {
+		// The token is redacted or substituted in dry-run output, so it is not obtained
+		ts = auth.NewStaticTokenSource(auth.Token{AccessToken: "dry-run"})
+	} else {
+		// Make sure a valid token exists. User Account authorization may be started here.
+		if _, err := getValidToken(profile, scopes); err != nil {
+			return nil, err
+		}
+		var err error
+		ts, err = getTokenSource(profile, scopes)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	shared := auth.HTTPClient()
+	httpClient := &http.Client{
+		Transport: &auth.Transport{Source: ts, Base: shared.Transport},
+	}
+	return api.NewClient(httpClient), nil
+}
+
+// Parse "key=value" fields
+func parseFields(fields []string) (map[string]string, error) {
+	m := map[string]string{}
+	for _, f := range fields {
+		kv := strings.SplitN(f, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid field '%s'. Must be key=value format", f)
+		}
+		m[kv[0]] = kv[1]
+	}
+	return m, nil
+}
+
+// Parse "Key: Value" headers
+func parseHeaders(headers []string) (http.Header, error) {
+	h := http.Header{}
+	for _, v := range headers {
+		kv := strings.SplitN(v, ":", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid header '%s'. Must be 'Key: Value' format", v)
+		}
+		h.Add(strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1]))
+	}
+	return h, nil
+}
+
+// Read request body from file. "-" means stdin.
+func readInput(input string) ([]byte, error) {
+	if input == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(input)
+}
+
+// Print response body. JSON is indented, or formatted by --output and --query.
+func printResponseBody(cmd *cobra.Command, body []byte) error {
+	if outputRequested(cmd) && json.Valid(body) {
+		return printOutput(cmd, json.RawMessage(body))
+	}
+
+	var buf bytes.Buffer
+	if json.Indent(&buf, body, "", "    ") == nil {
+		fmt.Printf("%s\n", buf.Bytes())
+		return nil
+	}
+	fmt.Printf("%s", body)
+	return nil
+}
+
+// Add flags for list commands
+func addPaginationFlags(c *cobra.Command) {
+	c.Flags().BoolP("paginate", "", false, "Follow cursors and get all pages")
+	c.Flags().IntP("limit", "", 0, "Max number of items. 0 means no limit")
+	c.Flags().BoolP("slurp", "", false, "With --paginate, merge items into one JSON array instead of NDJSON")
+}
+
+// Get all pages of a list API, and print items as NDJSON or one JSON array. header is sent on every page request.
+func printPaginated(cmd *cobra.Command, client *api.Client, path string, header http.Header) error {
+	limit, _ := cmd.Flags().GetInt("limit")
+	slurp, _ := cmd.Flags().GetBool("slurp")
+	// Formatted output needs all items
+	formatted := outputRequested(cmd)
+	slurp = slurp || formatted
+
+	items := []json.RawMessage{}
+	err := client.PaginateWithHeader(cmd.Context(), path, header, limit, func(item json.RawMessage) error {
+		if slurp {
+			items = append(items, item)
+			return nil
+		}
+		// NDJSON
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, item); err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", buf.Bytes())
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if formatted {
+		return printOutput(cmd, items)
+	}
+	if slurp {
+		b, err := json.MarshalIndent(items, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s\n", b)
+	}
+	return nil
+}
+
+// Print a list API. Only the first page is printed as is unless --paginate is given.
+func printList(cmd *cobra.Command, client *api.Client, path string) error {
+	paginate, _ := cmd.Flags().GetBool("paginate")
+	if paginate {
+		return printPaginated(cmd, client, path, nil)
+	}
+	page := map[string]interface{}{}
+	if err := client.Request(cmd.Context(), http.MethodGet, path, nil, nil, &page); err != nil {
+		return err
+	}
+	return printOutput(cmd, page)
+}
+
+var apiCmd = &cobra.Command{
+	Use:   "api [METHOD] PATH",
+	Short: "Make an authenticated API request.",
+	Long: `Make an authenticated API request with the profile's access token.
+
+PATH is resolved against the API base URL (` + api.BaseURL + `).
+Both "users/me" and "/v1.0/users/me" are accepted.
+
+METHOD defaults to GET, or POST if --field or --input is given.
+Fields are sent as query parameters for GET and DELETE, and as JSON body for others.
+
+With --paginate, list APIs are followed by cursor and items are printed as NDJSON (or one JSON array with --slurp).`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		scopes, _ := cmd.Flags().GetString("scopes")
+		fields, _ := cmd.Flags().GetStringArray("field")
+		input, _ := cmd.Flags().GetString("input")
+		headers, _ := cmd.Flags().GetStringArray("header")
+		include, _ := cmd.Flags().GetBool("include")
+		paginate, _ := cmd.Flags().GetBool("paginate")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		method := ""
+		path := args[0]
+		if len(args) == 2 {
+			method = strings.ToUpper(args[0])
+			path = args[1]
+		}
+		if method == "" {
+			method = http.MethodGet
+			if len(fields) > 0 || input != "" {
+				method = http.MethodPost
+			}
+		}
+
+		header, err := parseHeaders(headers)
+		if err != nil {
+			return err
+		}
+		fieldMap, err := parseFields(fields)
+		if err != nil {
+			return err
+		}
+
+		// Request body or query
+		var body io.Reader
+		if input != "" {
+			b, err := readInput(input)
+			if err != nil {
+				return err
+			}
+			body = bytes.NewReader(b)
+			if len(fieldMap) > 0 {
+				path = appendQuery(path, fieldMap)
+			}
+		} else if len(fieldMap) > 0 {
+			if method == http.MethodGet || method == http.MethodDelete {
+				path = appendQuery(path, fieldMap)
+			} else {
+				b, err := json.Marshal(fieldMap)
+				if err != nil {
+					return err
+				}
+				body = bytes.NewReader(b)
+			}
+		}
+
+		client, err := newAPIClient(profile, scopes)
+		if err != nil {
+			return err
+		}
+		if paginate {
+			if method != http.MethodGet {
+				return fmt.Errorf("--paginate is available only for GET")
+			}
+			return printPaginated(cmd, client, path, header)
+		}
+		res, err := client.Do(cmd.Context(), method, path, body, header)
+		if err != nil {
+			return err
+		}
+		defer res.Body.Close()
+
+		resBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			return err
+		}
+
+		if include {
+			fmt.Printf("%s %s\n", res.Proto, res.Status)
+			res.Header.Write(os.Stdout)
+			fmt.Println()
+		}
+		if err := printResponseBody(cmd, resBody); err != nil {
+			return err
+		}
+
+		if res.StatusCode >= 400 {
+			return fmt.Errorf("HTTP %s", res.Status)
+		}
+		return nil
+	},
+}
+
+func appendQuery(path string, fields map[string]string) string {
+	q := url.Values{}
+	for k, v := range fields {
+		q.Set(k, v)
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + q.Encode()
+}
+
+func init() {
+	rootCmd.AddCommand(apiCmd)
+
+	apiCmd.Flags().StringP("profile", "", "", "Profile name")
+	apiCmd.MarkFlagRequired("profile")
+	apiCmd.Flags().StringP("scopes", "", "", "Scopes which the token must cover")
+	apiCmd.Flags().StringArrayP("field", "f", []string{}, "Request field in key=value format")
+	apiCmd.Flags().StringP("input", "", "", "File path of request body. Use - for stdin")
+	apiCmd.Flags().StringArrayP("header", "H", []string{}, "Request header in 'Key: Value' format")
+	apiCmd.Flags().BoolP("include", "i", false, "Print response status and headers")
+	addPaginationFlags(apiCmd)
+}
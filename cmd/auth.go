@@ -2,9 +2,11 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -27,10 +29,148 @@ func getToken(profile string) (*auth.Token, error) {
 	return t, nil
 }
 
+const ENV_ACCESS_TOKEN = "LINEWORKS_ACCESS_TOKEN"
+
+type accessTokenOutput struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	Scopes      string `json:"scopes"`
+	ExpiresAt   string `json:"expires_at,omitempty"`
+}
+
+func newAccessTokenOutput(token *auth.Token) accessTokenOutput {
+	out := accessTokenOutput{
+		AccessToken: token.AccessToken,
+		TokenType:   token.TokenType,
+		Scopes:      token.Scopes,
+	}
+	if out.TokenType == "" {
+		out.TokenType = "Bearer"
+	}
+	if !token.ExpiresAt.IsZero() {
+		out.ExpiresAt = token.ExpiresAt.Format(time.RFC3339)
+	}
+	return out
+}
+
+type scopesOutput struct {
+	Scopes          []string `json:"scopes"`
+	RequestedScopes []string `json:"requested_scopes"`
+	NotGranted      []string `json:"not_granted"`
+	NotRequested    []string `json:"not_requested"`
+}
+
+// Format access token for other tools
+func formatToken(token *auth.Token, format string) (string, error) {
+	tokenType := token.TokenType
+	if tokenType == "" {
+		tokenType = "Bearer"
+	}
+
+	switch format {
+	case "", "raw":
+		return token.AccessToken, nil
+	case "json":
+		b, err := json.MarshalIndent(newAccessTokenOutput(token), "", "    ")
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s\n", b), nil
+	case "env":
+		return fmt.Sprintf("export %s=%s\n", ENV_ACCESS_TOKEN, token.AccessToken), nil
+	case "header":
+		return fmt.Sprintf("Authorization: %s %s\n", tokenType, token.AccessToken), nil
+	case "dotenv":
+		return fmt.Sprintf("%s=%s\n", ENV_ACCESS_TOKEN, token.AccessToken), nil
+	}
+	return "", fmt.Errorf("unknown format '%s'. Must be one of raw, json, env, header, dotenv", format)
+}
+
+// Get a token source of the profile.
+// In replay, a dummy token is used without requesting tokens, so that the stored token is neither needed nor replaced.
+func getTokenSource(profile string, scopes string) (*auth.TokenSource, error) {
+	if replaying {
+		return auth.NewStaticTokenSource(auth.Token{
+			AccessToken: auth.REPLAY_ACCESS_TOKEN,
+			TokenType:   "Bearer",
+			Scopes:      auth.NormalizeScopes(scopes),
+		}), nil
+	}
+	ts, err := auth.NewProfileTokenSource(profile, scopes)
+	if os.IsNotExist(err) {
+		return nil, errors.New("profile does not exist.")
+	}
+	return ts, err
+}
+
+// Whether stdin is a terminal, so that the user can take part in authorization
+var stdinIsTerminal = func() bool {
+	fi, err := os.Stdin.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// Get a valid access token, renewing it if needed.
+// If scopes are given, the token covers them. When it can not be obtained without user interaction,
+// User Account authorization is started if stdin is a terminal.
+func getValidToken(profile string, scopes string) (*auth.Token, error) {
+	ts, err := getTokenSource(profile, scopes)
+	if err != nil {
+		return nil, err
+	}
+	token, err := ts.AccessToken()
+	if scopes == "" || !errors.Is(err, auth.ErrTokenUnavailable) {
+		return token, err
+	}
+	if !stdinIsTerminal() {
+		return nil, fmt.Errorf("%w with --scopes \"%s\". It is not started here because stdin is not a terminal", err, scopes)
+	}
+
+	cred, err := getClientConfigure(profile)
+	if err != nil {
+		return nil, err
+	}
+	cred.Scopes = auth.NormalizeScopes(scopes)
+	if err := authUserAccount(profile, cred, 120); err != nil {
+		return nil, err
+	}
+
+	ts, err = getTokenSource(profile, scopes)
+	if err != nil {
+		return nil, err
+	}
+	return ts.AccessToken()
+}
+
+// Resolve scopes from flags, preset and configured scopes.
+// Scopes given by flags or preset are validated. Configured scopes are used as they are.
+func resolveScopes(profile string, scopes string, preset string, configured string, skipValidation bool) (string, error) {
+	if scopes == "" && preset != "" {
+		s, err := auth.ResolveScopePreset(profile, preset)
+		if err != nil {
+			return "", err
+		}
+		scopes = s
+	}
+	if scopes == "" {
+		return configured, nil
+	}
+	if skipValidation {
+		return scopes, nil
+	}
+	if err := auth.ValidateScopes(scopes); err != nil {
+		return "", err
+	}
+	return scopes, nil
+}
+
 // User Account Auth
 func authUserAccount(profile string, clientCred *auth.ClientCredential, timeoutSec int16) error {
 	if clientCred.Scopes == "" {
-		return errors.New("'scopes' does not set.\n")
+		return errors.New("'scopes' does not set.")
+	}
+	if replaying {
+		// No token is requested nor saved in replay
+		return nil
 	}
 	ctx := context.Background()
 
@@ -40,30 +180,52 @@ func authUserAccount(profile string, clientCred *auth.ClientCredential, timeoutS
 	time.Sleep(1 * time.Second)
 	browser.OpenURL(url)
 
+	// Result of the first callback
+	result := make(chan error, 1)
 	auth.StartCallbackServer(ctx, clientCred.ListenAddr, clientCred.ListenPort, clientCred.RedirectPath, timeoutSec,
 		func(code string, state string) error {
-			if state != stateReq.String() {
-				return errors.New("'state' does not match")
-			}
+			err := func() error {
+				if state != stateReq.String() {
+					return errors.New("'state' does not match")
+				}
 
-			// Get AccessToken
-			tok := clientCred.GetAccessToken(code)
-			tok.WriteConfig(profile)
-			return nil
+				// Get AccessToken
+				tok, err := clientCred.FetchAccessToken(code)
+				if err != nil {
+					return err
+				}
+				return auth.SaveToken(profile, &tok)
+			}()
+			select {
+			case result <- err:
+			default:
+			}
+			return err
 		})
 
-	return nil
+	select {
+	case err := <-result:
+		return err
+	default:
+		return errors.New("authorization is not completed")
+	}
 }
 
 // Service Account Auth
 func authServiceAccount(profile string, clientCred *auth.ClientCredential, serviceAccount *auth.ServiceAccount) error {
 	if clientCred.Scopes == "" {
-		return errors.New("'scopes' does not set.\n")
+		return errors.New("'scopes' does not set.")
+	}
+	if replaying {
+		// No token is requested nor saved in replay
+		return nil
 	}
 
-	tok := clientCred.GetAccessTokenJWT(*serviceAccount)
-	tok.WriteConfig(profile)
-	return nil
+	tok, err := clientCred.FetchAccessTokenJWT(*serviceAccount)
+	if err != nil {
+		return err
+	}
+	return auth.SaveToken(profile, &tok)
 }
 
 var authCmd = &cobra.Command{
@@ -81,14 +243,20 @@ var authUserAccountCmd = &cobra.Command{
 		port, _ := cmd.Flags().GetString("port")
 		path, _ := cmd.Flags().GetString("path")
 		timeout_sec, _ := cmd.Flags().GetInt16("timeout")
+		scope_preset, _ := cmd.Flags().GetString("scope-preset")
+		skip_validation, _ := cmd.Flags().GetBool("skip-scope-validation")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
 		cred, err := getClientConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
 		}
 
-		if scopes != "" {
-			cred.Scopes = scopes
+		cred.Scopes, err = resolveScopes(profile, scopes, scope_preset, cred.Scopes, skip_validation)
+		if err != nil {
+			return err
 		}
 		if addr != "" {
 			cred.ListenAddr = addr
@@ -99,12 +267,7 @@ var authUserAccountCmd = &cobra.Command{
 		if path != "" {
 			cred.RedirectPath = path
 		}
-		err = authUserAccount(profile, cred, timeout_sec)
-		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
-		}
-		return nil
+		return authUserAccount(profile, cred, timeout_sec)
 	},
 }
 
@@ -114,24 +277,27 @@ var authServiceAccountCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, _ := cmd.Flags().GetString("profile")
 		scopes, _ := cmd.Flags().GetString("scopes")
+		scope_preset, _ := cmd.Flags().GetString("scope-preset")
+		skip_validation, _ := cmd.Flags().GetBool("skip-scope-validation")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
 		cred, err := getClientConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
 		}
 
-		if scopes != "" {
-			cred.Scopes = scopes
+		cred.Scopes, err = resolveScopes(profile, scopes, scope_preset, cred.Scopes, skip_validation)
+		if err != nil {
+			return err
 		}
 		sa, err := getServiceAccountConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
 		}
-		err = authServiceAccount(profile, cred, sa)
-		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+		if err := authServiceAccount(profile, cred, sa); err != nil {
+			return err
 		}
 
 		fmt.Printf("Success\n")
@@ -144,12 +310,31 @@ var authGetAccessTokenCmd = &cobra.Command{
 	Short: "Get access token",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, _ := cmd.Flags().GetString("profile")
-		token, err := getToken(profile)
+		scopes, _ := cmd.Flags().GetString("scopes")
+		format, _ := cmd.Flags().GetString("format")
+		file, _ := cmd.Flags().GetString("file")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		token, err := getValidToken(profile, scopes)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
+		}
+
+		if outputRequested(cmd) {
+			return printOutput(cmd, newAccessTokenOutput(token))
+		}
+
+		out, err := formatToken(token, format)
+		if err != nil {
+			return err
+		}
+		if file != "" {
+			// The file contains the credential, so only the owner can read it
+			return os.WriteFile(file, []byte(out), 0600)
 		}
-		fmt.Printf("%s", token.AccessToken)
+		fmt.Printf("%s", out)
 		return nil
 	},
 }
@@ -159,12 +344,36 @@ var authGetScopesCmd = &cobra.Command{
 	Short: "Get scopes which the access token has.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
 		token, err := getToken(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
+		}
+		missing, extra := auth.DiffScopes(token.RequestedScopes, token.Scopes)
+		if outputRequested(cmd) {
+			out := scopesOutput{
+				Scopes:          auth.SplitScopes(token.Scopes),
+				RequestedScopes: auth.SplitScopes(token.RequestedScopes),
+				NotGranted:      missing,
+				NotRequested:    extra,
+			}
+			return printOutput(cmd, out)
+		}
+
+		fmt.Printf("%s\n", token.Scopes)
+
+		// Diff between requested and granted scopes
+		if token.RequestedScopes != "" {
+			if len(missing) > 0 {
+				fmt.Printf("Requested but not granted: %s\n", strings.Join(missing, ","))
+			}
+			if len(extra) > 0 {
+				fmt.Printf("Granted but not requested: %s\n", strings.Join(extra, ","))
+			}
 		}
-		fmt.Printf("%s", token.Scopes)
 		return nil
 	},
 }
@@ -184,6 +393,14 @@ func init() {
 	authUserAccountCmd.Flags().StringP("port", "", "", "Listening port of callback server")
 	authUserAccountCmd.Flags().StringP("path", "", "", "URL path of callback server")
 	authUserAccountCmd.Flags().Int16P("timeout", "", 120, "Timeout secound.")
+	authUserAccountCmd.Flags().StringP("scope-preset", "", "", "Scope preset name. Used when --scopes is not set")
+	authUserAccountCmd.Flags().BoolP("skip-scope-validation", "", false, "Skip validation of scopes against known scopes")
+
+	authGetAccessTokenCmd.Flags().StringP("scopes", "", "", "Scopes which the token must cover. A cached token is returned if exists")
+	authGetAccessTokenCmd.Flags().StringP("format", "", "raw", "Output format. raw, json, env, header or dotenv")
+	authGetAccessTokenCmd.Flags().StringP("file", "", "", "Write the output to the file instead of stdout (ex. .env with --format dotenv)")
 
 	authServiceAccountCmd.Flags().StringP("scopes", "", "", "Scopes. Must be comma-delimited format (ex. bot,user.read,board)")
+	authServiceAccountCmd.Flags().StringP("scope-preset", "", "", "Scope preset name. Used when --scopes is not set")
+	authServiceAccountCmd.Flags().BoolP("skip-scope-validation", "", false, "Skip validation of scopes against known scopes")
 }
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/mmclsntr/lineworks-cli/auth"
+)
+
+func TestGetValidTokenNotInteractive(t *testing.T) {
+	t.Setenv(auth.CONFIG_PATH_ENV_NAME, t.TempDir())
+	if err := setClientConfigure("p", "id", "secret", "bot", "", "", "", "", ""); err != nil {
+		t.Fatal(err)
+	}
+	isTerminal := stdinIsTerminal
+	stdinIsTerminal = func() bool { return false }
+	defer func() { stdinIsTerminal = isTerminal }()
+
+	_, err := getValidToken("p", "bot")
+	if !errors.Is(err, auth.ErrTokenUnavailable) {
+		t.Fatalf("getValidToken() = %v, want ErrTokenUnavailable", err)
+	}
+	if !strings.Contains(err.Error(), "auth user-account") {
+		t.Errorf("error %q has no hint to authorize", err)
+	}
+}
+
+func TestResolveScopes(t *testing.T) {
+	t.Setenv(auth.CONFIG_PATH_ENV_NAME, t.TempDir())
+	cases := []struct {
+		name           string
+		scopes         string
+		preset         string
+		configured     string
+		skipValidation bool
+		want           string
+		err            bool
+	}{
+		{"flag", "bot", "", "user.read", false, "bot", false},
+		{"preset", "", "bot", "user.read", false, auth.BuiltinScopePresets["bot"], false},
+		{"flag over preset", "mail", "bot", "", false, "mail", false},
+		{"configured", "", "", "user.read", false, "user.read", false},
+		{"unknown configured scope is not validated", "", "", "legacy.scope", false, "legacy.scope", false},
+		{"unknown flag scope", "bto", "", "bot", false, "", true},
+		{"unknown flag scope without validation", "bto", "", "", true, "bto", false},
+		{"unknown preset", "", "none", "bot", false, "", true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got, err := resolveScopes("p", c.scopes, c.preset, c.configured, c.skipValidation)
+			if (err != nil) != c.err || got != c.want {
+				t.Errorf("resolveScopes() = %q, %v, want %q", got, err, c.want)
+			}
+		})
+	}
+}
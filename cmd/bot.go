@@ -0,0 +1,296 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/auth"
+)
+
+// Scopes required by bot commands
+const BOT_SCOPES = "bot"
+
+// Recipient of bot messages. Either of UserID or ChannelID is set.
+type botRecipient struct {
+	UserID    string
+	ChannelID string
+}
+
+// Get recipient from --user and --channel. The default channel of the bot is used if neither is set.
+func getBotRecipient(cmd *cobra.Command, bot *auth.Bot) (botRecipient, error) {
+	user, _ := cmd.Flags().GetString("user")
+	channel, _ := cmd.Flags().GetString("channel")
+
+	if user != "" && channel != "" {
+		return botRecipient{}, fmt.Errorf("--user and --channel can not be used together")
+	}
+	if user == "" && channel == "" {
+		channel = bot.DefaultChannel
+	}
+	if user == "" && channel == "" {
+		return botRecipient{}, fmt.Errorf("--user or --channel is required. The bot has no default channel")
+	}
+	return botRecipient{UserID: user, ChannelID: channel}, nil
+}
+
+// Send message content to the recipient
+func sendBotMessage(ctx context.Context, client *api.Client, bot *auth.Bot, to botRecipient, content interface{}) error {
+	if to.UserID != "" {
+		return client.SendMessageToUser(ctx, bot.BotID, to.UserID, content)
+	}
+	return client.SendMessageToChannel(ctx, bot.BotID, to.ChannelID, content)
+}
+
+// Read text. "-" means stdin, and the trailing newline is removed.
+func readText(text string) (string, error) {
+	if text != "-" {
+		return text, nil
+	}
+	b, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+// Read message content from JSON or YAML file. "-" means stdin.
+func readMessageContent(file string) (map[string]interface{}, error) {
+	b, err := readInput(file)
+	if err != nil {
+		return nil, err
+	}
+	return parseMessageContent(b, file)
+}
+
+// Parse message content in JSON or YAML.
+// Both the content object and the message body ({"content": {...}}) are accepted.
+func parseMessageContent(b []byte, name string) (map[string]interface{}, error) {
+	// JSON is also parsed as YAML
+	var content map[string]interface{}
+	if err := yaml.Unmarshal(b, &content); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", name, err)
+	}
+	if inner, ok := content["content"].(map[string]interface{}); ok && content["type"] == nil {
+		content = inner
+	}
+	if content == nil {
+		return nil, fmt.Errorf("%s is empty", name)
+	}
+	return content, nil
+}
+
+// Render the message template and convert to validated content
+func renderMessageContent(tmpl *template.Template, file string, data interface{}) (interface{}, error) {
+	b, err := renderTemplate(tmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	if isContentTemplate(file) {
+		content, err := parseMessageContent(b, file)
+		if err != nil {
+			return nil, err
+		}
+		if err := api.ValidateContent(content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	}
+
+	text := strings.TrimRight(string(b), "\r\n")
+	if err := api.ValidateText(text); err != nil {
+		return nil, err
+	}
+	return api.NewTextContent(text), nil
+}
+
+// Get validated message content from --text, --message or --template
+func getMessageContent(cmd *cobra.Command) (interface{}, error) {
+	text, _ := cmd.Flags().GetString("text")
+	message, _ := cmd.Flags().GetString("message")
+	template_file, _ := cmd.Flags().GetString("template")
+
+	set := 0
+	for _, v := range []string{text, message, template_file} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("one of --text, --message or --template is required")
+	}
+
+	switch {
+	case template_file != "":
+		data, err := loadTemplateVars(cmd)
+		if err != nil {
+			return nil, err
+		}
+		tmpl, err := parseTemplate(template_file)
+		if err != nil {
+			return nil, err
+		}
+		return renderMessageContent(tmpl, template_file, data)
+	case message != "":
+		content, err := readMessageContent(message)
+		if err != nil {
+			return nil, err
+		}
+		if err := api.ValidateContent(content); err != nil {
+			return nil, err
+		}
+		return content, nil
+	}
+
+	text, err := readText(text)
+	if err != nil {
+		return nil, err
+	}
+	if err := api.ValidateText(text); err != nil {
+		return nil, err
+	}
+	return api.NewTextContent(text), nil
+}
+
+// Print upload progress to stderr
+func newUploadProgress(name string) api.ProgressFunc {
+	last := int64(-1)
+	return func(sent int64, total int64) {
+		percent := int64(100)
+		if total > 0 {
+			percent = sent * 100 / total
+		}
+		if percent == last {
+			return
+		}
+		last = percent
+		fmt.Fprintf(os.Stderr, "\rUploading %s: %3d%% (%d / %d bytes)", name, percent, sent, total)
+		if sent >= total {
+			fmt.Fprintln(os.Stderr)
+		}
+	}
+}
+
+// Upload the file as an attachment of the bot, and get file or image message content
+func uploadMessageContent(ctx context.Context, client *api.Client, bot *auth.Bot, file string, image bool) (interface{}, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	typ := "file"
+	if image {
+		typ = "image"
+		if contentType := api.DetectContentType(file, data); !strings.HasPrefix(contentType, "image/") {
+			return nil, fmt.Errorf("%s is not an image (%s)", file, contentType)
+		}
+	}
+
+	fileID, err := client.UploadFile(ctx, bot.BotID, file, data, newUploadProgress(filepath.Base(file)))
+	if err != nil {
+		return nil, err
+	}
+	return api.NewFileContent(typ, fileID), nil
+}
+
+var botCmd = &cobra.Command{
+	Use:   "bot",
+	Short: "Send messages and manage bots.",
+}
+
+var botSendCmd = &cobra.Command{
+	Use:   "send",
+	Short: "Send a message to a user or a channel.",
+	Long: `Send a message to a user or a channel by the bot.
+
+The default channel of the bot is used if neither --user nor --channel is set.
+Use "--text -" to read text from stdin.
+
+Rich messages (button_template, list_template, carousel, image_carousel, sticker, link, flex, etc.) are sent by --message
+from a JSON or YAML file of the content object. The content is validated before sending.
+
+--template renders a Go text/template file with variables of --var and --vars-file.
+msg.json.tmpl and msg.yaml.tmpl are rendered to rich message content, and others to text.
+
+--file and --image upload the file as an attachment, and send it as a file or image message.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		bot_name, _ := cmd.Flags().GetString("bot")
+		file, _ := cmd.Flags().GetString("file")
+		image, _ := cmd.Flags().GetString("image")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot, err := getBotConfigure(profile, bot_name)
+		if err != nil {
+			return err
+		}
+		to, err := getBotRecipient(cmd, bot)
+		if err != nil {
+			return err
+		}
+
+		// Attachment
+		if file != "" || image != "" {
+			for _, name := range []string{"text", "message", "template"} {
+				if cmd.Flags().Changed(name) {
+					return fmt.Errorf("--%s can not be used with --file or --image", name)
+				}
+			}
+			if file != "" && image != "" {
+				return fmt.Errorf("--file and --image can not be used together")
+			}
+			client, err := newAPIClient(profile, BOT_SCOPES)
+			if err != nil {
+				return err
+			}
+			content, err := uploadMessageContent(cmd.Context(), client, bot, file+image, image != "")
+			if isDryRun(err) {
+				// The upload and the message use the file ID of the created attachment, so their requests cannot be shown
+				fmt.Fprintf(os.Stderr, "dry run: %s will be uploaded and sent after the attachment is created\n", file+image)
+			}
+			if err != nil {
+				return err
+			}
+			return sendBotMessage(cmd.Context(), client, bot, to, content)
+		}
+
+		content, err := getMessageContent(cmd)
+		if err != nil {
+			return err
+		}
+
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return sendBotMessage(cmd.Context(), client, bot, to, content)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(botCmd)
+	botCmd.AddCommand(botSendCmd)
+
+	botCmd.PersistentFlags().StringP("profile", "", "", "Profile name")
+	botCmd.MarkPersistentFlagRequired("profile")
+	botCmd.PersistentFlags().StringP("bot", "", "", "Bot name in the profile. The default bot is used if not set")
+
+	botSendCmd.Flags().StringP("user", "", "", "User ID to send to")
+	botSendCmd.Flags().StringP("channel", "", "", "Channel ID to send to")
+	botSendCmd.Flags().StringP("text", "", "", "Text message. Use - for stdin")
+	botSendCmd.Flags().StringP("message", "", "", "JSON or YAML file of message content. Use - for stdin")
+	botSendCmd.Flags().StringP("template", "", "", "Go template file of text or message content")
+	addTemplateVarFlags(botSendCmd)
+	botSendCmd.Flags().StringP("file", "", "", "File path to upload and send as a file message")
+	botSendCmd.Flags().StringP("image", "", "", "Image file path to upload and send as an image message")
+}
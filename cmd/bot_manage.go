@@ -0,0 +1,477 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/auth"
+)
+
+// Read a JSON or YAML file of an object. "-" means stdin.
+func readObjectFile(file string) (map[string]interface{}, error) {
+	b, err := readInput(file)
+	if err != nil {
+		return nil, err
+	}
+	// JSON is also parsed as YAML
+	var obj map[string]interface{}
+	if err := yaml.Unmarshal(b, &obj); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	if obj == nil {
+		return nil, fmt.Errorf("%s is empty", file)
+	}
+	return obj, nil
+}
+
+// Get the target bot ID from the argument, or the bot of --bot in the profile
+func getTargetBotID(cmd *cobra.Command, args []string) (string, error) {
+	if len(args) > 0 {
+		return args[0], nil
+	}
+	profile, _ := cmd.Flags().GetString("profile")
+	bot_name, _ := cmd.Flags().GetString("bot")
+	bot, err := getBotConfigure(profile, bot_name)
+	if err != nil {
+		return "", err
+	}
+	return bot.BotID, nil
+}
+
+// Add flags of bot settings
+func addBotSettingsFlags(c *cobra.Command) {
+	c.Flags().StringP("input", "", "", "JSON or YAML file of bot settings. Flags override it. Use - for stdin")
+	c.Flags().StringP("name", "", "", "Bot name")
+	c.Flags().StringP("photo-url", "", "", "HTTPS URL of the bot photo (PNG)")
+	c.Flags().StringP("description", "", "", "Description")
+	c.Flags().StringArrayP("administrator", "", nil, "User ID of an administrator. Can be repeated")
+	c.Flags().StringP("callback-url", "", "", "HTTPS URL to receive callback events")
+	c.Flags().StringArrayP("callback-event", "", nil, "Callback event to receive (text, location, sticker, image, file). Can be repeated")
+	c.Flags().BoolP("enable-callback", "", false, "Enable callback. Enabled by --callback-url if not set")
+}
+
+// Build bot settings from --input and the changed flags
+func getBotSettings(cmd *cobra.Command) (map[string]interface{}, error) {
+	input, _ := cmd.Flags().GetString("input")
+
+	settings := map[string]interface{}{}
+	if input != "" {
+		var err error
+		settings, err = readObjectFile(input)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	texts := map[string]string{
+		"name":         "botName",
+		"photo-url":    "photoUrl",
+		"description":  "description",
+		"callback-url": "callbackUrl",
+	}
+	for flag, key := range texts {
+		if cmd.Flags().Changed(flag) {
+			settings[key], _ = cmd.Flags().GetString(flag)
+		}
+	}
+	arrays := map[string]string{
+		"administrator":  "administrators",
+		"callback-event": "callbackEvents",
+	}
+	for flag, key := range arrays {
+		if cmd.Flags().Changed(flag) {
+			values, _ := cmd.Flags().GetStringArray(flag)
+			list := []interface{}{}
+			for _, v := range values {
+				list = append(list, v)
+			}
+			settings[key] = list
+		}
+	}
+	if cmd.Flags().Changed("enable-callback") {
+		settings["enableCallback"], _ = cmd.Flags().GetBool("enable-callback")
+	} else if cmd.Flags().Changed("callback-url") {
+		settings["enableCallback"] = true
+	}
+	return settings, nil
+}
+
+// Format an ID in a response. Numeric IDs are decoded as float64.
+func formatID(v interface{}) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return fmt.Sprint(v)
+}
+
+// Save the registered bot to the profile
+func saveRegisteredBot(profile string, name string, registered map[string]interface{}) error {
+	if registered["botId"] == nil {
+		return errors.New("botId is not in the response")
+	}
+	bot_id := formatID(registered["botId"])
+	bot_secret, _ := registered["botSecret"].(string)
+
+	bots, err := getBotsConfigure(profile)
+	if err != nil {
+		return err
+	}
+	bots.Bots[name] = auth.Bot{BotID: bot_id, BotSecret: bot_secret}
+	if len(bots.Bots) == 1 {
+		bots.Default = name
+	}
+	return bots.WriteConfig(profile)
+}
+
+// Ask for confirmation on w and read the answer from r. Only "y" or "yes" confirms.
+func confirm(r io.Reader, w io.Writer, prompt string) bool {
+	fmt.Fprintf(w, "%s [y/N]: ", prompt)
+	answer, _ := bufio.NewReader(r).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
+// Remove saved bots of the bot ID from the profile, and return their names
+func removeSavedBots(profile string, bot_id string) ([]string, error) {
+	bots, err := getBotsConfigure(profile)
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, name := range bots.Names() {
+		if bots.Bots[name].BotID != bot_id {
+			continue
+		}
+		delete(bots.Bots, name)
+		if bots.Default == name {
+			bots.Default = ""
+		}
+		removed = append(removed, name)
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+	return removed, bots.WriteConfig(profile)
+}
+
+var botListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List bots of the tenant.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return printList(cmd, client, "bots")
+	},
+}
+
+var botGetCmd = &cobra.Command{
+	Use:   "get [BOT_ID]",
+	Short: "Get a bot. The bot of --bot is used if BOT_ID is not given.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, args)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		bot, err := client.GetBot(cmd.Context(), bot_id)
+		if err != nil {
+			return err
+		}
+		return printOutput(cmd, bot)
+	},
+}
+
+var botCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Register a bot.",
+	Long: `Register a bot with settings of --input and flags.
+
+The bot photo must be a PNG image served on an HTTPS URL (--photo-url).
+With --save NAME, the bot ID and secret are saved to the profile as a named bot.
+
+  lineworks bot create --name "Team bot" --photo-url https://example.com/bot.png \
+    --administrator USER_ID --callback-url https://example.com/callback --save team --profile PROFILE`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		save, _ := cmd.Flags().GetString("save")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		settings, err := getBotSettings(cmd)
+		if err != nil {
+			return err
+		}
+		if err := api.ValidateBotSettings(settings, true); err != nil {
+			return err
+		}
+
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		bot, err := client.CreateBot(cmd.Context(), settings)
+		if err != nil {
+			return err
+		}
+		if save != "" {
+			if err := saveRegisteredBot(profile, save, bot); err != nil {
+				return err
+			}
+		}
+		return printOutput(cmd, bot)
+	},
+}
+
+var botUpdateCmd = &cobra.Command{
+	Use:   "update [BOT_ID]",
+	Short: "Update settings of a bot. Only given settings are updated.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		settings, err := getBotSettings(cmd)
+		if err != nil {
+			return err
+		}
+		if len(settings) == 0 {
+			return errors.New("no settings to update")
+		}
+		if err := api.ValidateBotSettings(settings, false); err != nil {
+			return err
+		}
+		bot_id, err := getTargetBotID(cmd, args)
+		if err != nil {
+			return err
+		}
+
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		bot, err := client.UpdateBot(cmd.Context(), bot_id, settings)
+		if err != nil {
+			return err
+		}
+		return printOutput(cmd, bot)
+	},
+}
+
+var botDeleteCmd = &cobra.Command{
+	Use:   "delete BOT_ID",
+	Short: "Delete a bot. Saved bots of the ID are removed from the profile.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		yes, _ := cmd.Flags().GetBool("yes")
+		bot_id := args[0]
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		if !yes && !dryRun && !confirm(os.Stdin, os.Stderr, fmt.Sprintf("Delete bot %s?", bot_id)) {
+			return errors.New("canceled. Use --yes to delete without confirmation")
+		}
+
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		if err := client.DeleteBot(cmd.Context(), bot_id); err != nil {
+			return err
+		}
+		if replaying {
+			// The profile is not changed in replay
+			return nil
+		}
+		removed, err := removeSavedBots(profile, bot_id)
+		if err != nil {
+			return err
+		}
+		for _, name := range removed {
+			fmt.Fprintf(os.Stderr, "Removed bot '%s' from the profile\n", name)
+		}
+		return nil
+	},
+}
+
+var botDomainCmd = &cobra.Command{
+	Use:   "domain",
+	Short: "Manage domain registration of a bot.",
+}
+
+// Get the domain ID from --domain-id, or the client settings of the profile
+func getTargetDomainID(cmd *cobra.Command) (string, error) {
+	profile, _ := cmd.Flags().GetString("profile")
+	domain_id, _ := cmd.Flags().GetString("domain-id")
+	if domain_id != "" {
+		return domain_id, nil
+	}
+	cred, err := getClientConfigure(profile)
+	if err != nil {
+		return "", err
+	}
+	if cred.DomainID == "" {
+		return "", errors.New("--domain-id is required. The profile has no domain ID")
+	}
+	return cred.DomainID, nil
+}
+
+var botDomainRegisterCmd = &cobra.Command{
+	Use:   "register [BOT_ID]",
+	Short: "Register a bot to the domain, so that members can use it.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		input, _ := cmd.Flags().GetString("input")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, args)
+		if err != nil {
+			return err
+		}
+		domain_id, err := getTargetDomainID(cmd)
+		if err != nil {
+			return err
+		}
+
+		settings := map[string]interface{}{}
+		if input != "" {
+			settings, err = readObjectFile(input)
+			if err != nil {
+				return err
+			}
+		}
+		if cmd.Flags().Changed("use-public") {
+			settings["usePublic"], _ = cmd.Flags().GetBool("use-public")
+		}
+		if cmd.Flags().Changed("use-permission") {
+			settings["usePermission"], _ = cmd.Flags().GetBool("use-permission")
+		}
+
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		domain, err := client.RegisterBotDomain(cmd.Context(), bot_id, domain_id, settings)
+		if err != nil {
+			return err
+		}
+		return printOutput(cmd, domain)
+	},
+}
+
+var botDomainGetCmd = &cobra.Command{
+	Use:   "get [BOT_ID]",
+	Short: "Get the domain registration of a bot.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, args)
+		if err != nil {
+			return err
+		}
+		domain_id, err := getTargetDomainID(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		domain, err := client.GetBotDomain(cmd.Context(), bot_id, domain_id)
+		if err != nil {
+			return err
+		}
+		return printOutput(cmd, domain)
+	},
+}
+
+var botDomainUnregisterCmd = &cobra.Command{
+	Use:   "unregister [BOT_ID]",
+	Short: "Unregister a bot from the domain.",
+	Args:  cobra.MaximumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, args)
+		if err != nil {
+			return err
+		}
+		domain_id, err := getTargetDomainID(cmd)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return client.UnregisterBotDomain(cmd.Context(), bot_id, domain_id)
+	},
+}
+
+func init() {
+	botCmd.AddCommand(botListCmd)
+	botCmd.AddCommand(botGetCmd)
+	botCmd.AddCommand(botCreateCmd)
+	botCmd.AddCommand(botUpdateCmd)
+	botCmd.AddCommand(botDeleteCmd)
+	botCmd.AddCommand(botDomainCmd)
+	botDomainCmd.AddCommand(botDomainRegisterCmd)
+	botDomainCmd.AddCommand(botDomainGetCmd)
+	botDomainCmd.AddCommand(botDomainUnregisterCmd)
+
+	addPaginationFlags(botListCmd)
+
+	addBotSettingsFlags(botCreateCmd)
+	botCreateCmd.Flags().StringP("save", "", "", "Save the registered bot to the profile by the name")
+	addBotSettingsFlags(botUpdateCmd)
+	botDeleteCmd.Flags().BoolP("yes", "", false, "Delete without confirmation")
+
+	botDomainCmd.PersistentFlags().StringP("domain-id", "", "", "Domain ID. The domain ID of the profile is used if not set")
+	botDomainRegisterCmd.Flags().StringP("input", "", "", "JSON or YAML file of domain settings. Use - for stdin")
+	botDomainRegisterCmd.Flags().BoolP("use-public", "", false, "Allow all members to use the bot")
+	botDomainRegisterCmd.Flags().BoolP("use-permission", "", false, "Restrict the bot to permitted members")
+}
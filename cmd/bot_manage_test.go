@@ -0,0 +1,135 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/auth"
+)
+
+func TestFormatID(t *testing.T) {
+	cases := []struct {
+		v    interface{}
+		want string
+	}{
+		{float64(2000001), "2000001"},
+		{float64(1234567890123), "1234567890123"},
+		{"2000001", "2000001"},
+		{"bot-id", "bot-id"},
+	}
+	for _, c := range cases {
+		if got := formatID(c.v); got != c.want {
+			t.Errorf("formatID(%v) = %s, want %s", c.v, got, c.want)
+		}
+	}
+}
+
+func TestGetBotSettings(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "bot.yaml")
+	content := "botName: From input\ndescription: Input description\nadministrators: [u1]\nenableCallback: false\n"
+	if err := os.WriteFile(input, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		args []string
+		want map[string]interface{}
+	}{
+		{"no settings", []string{}, map[string]interface{}{}},
+		{"flags", []string{"--name", "Bot", "--photo-url", "https://example.com/a.png", "--administrator", "u1", "--administrator", "u2"},
+			map[string]interface{}{"botName": "Bot", "photoUrl": "https://example.com/a.png", "administrators": []interface{}{"u1", "u2"}}},
+		{"callback url enables callback", []string{"--callback-url", "https://example.com/cb", "--callback-event", "text"},
+			map[string]interface{}{"callbackUrl": "https://example.com/cb", "callbackEvents": []interface{}{"text"}, "enableCallback": true}},
+		{"enable-callback overrides the implied one", []string{"--callback-url", "https://example.com/cb", "--enable-callback=false"},
+			map[string]interface{}{"callbackUrl": "https://example.com/cb", "enableCallback": false}},
+		{"input", []string{"--input", input},
+			map[string]interface{}{"botName": "From input", "description": "Input description", "administrators": []interface{}{"u1"}, "enableCallback": false}},
+		{"flags override input", []string{"--input", input, "--name", "Bot", "--administrator", "u2", "--callback-url", "https://example.com/cb"},
+			map[string]interface{}{"botName": "Bot", "description": "Input description", "administrators": []interface{}{"u2"}, "callbackUrl": "https://example.com/cb", "enableCallback": true}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			cmd := &cobra.Command{}
+			addBotSettingsFlags(cmd)
+			if err := cmd.Flags().Parse(c.args); err != nil {
+				t.Fatal(err)
+			}
+			got, err := getBotSettings(cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("getBotSettings() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestConfirm(t *testing.T) {
+	cases := []struct {
+		answer string
+		want   bool
+	}{
+		{"y\n", true},
+		{"Yes\n", true},
+		{" y \r\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+		{"yes please\n", false},
+	}
+	for _, c := range cases {
+		var out bytes.Buffer
+		if got := confirm(strings.NewReader(c.answer), &out, "Delete bot 1?"); got != c.want {
+			t.Errorf("confirm(%q) = %v, want %v", c.answer, got, c.want)
+		}
+		if out.String() != "Delete bot 1? [y/N]: " {
+			t.Errorf("prompt = %q", out.String())
+		}
+	}
+}
+
+func TestRemoveSavedBots(t *testing.T) {
+	t.Setenv(auth.CONFIG_PATH_ENV_NAME, t.TempDir())
+	bots := &auth.Bots{
+		Default: "alert",
+		Bots: map[string]auth.Bot{
+			"alert":  {BotID: "1"},
+			"alert2": {BotID: "1"},
+			"ops":    {BotID: "2"},
+		},
+	}
+	if err := bots.WriteConfig("p"); err != nil {
+		t.Fatal(err)
+	}
+
+	removed, err := removeSavedBots("p", "1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(removed, []string{"alert", "alert2"}) {
+		t.Errorf("removed = %v", removed)
+	}
+	saved, err := getBotsConfigure("p")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(saved.Names(), []string{"ops"}) {
+		t.Errorf("saved bots = %v, want [ops]", saved.Names())
+	}
+	if saved.Default != "" {
+		t.Errorf("default = %s, want none", saved.Default)
+	}
+
+	removed, err = removeSavedBots("p", "3")
+	if err != nil || len(removed) != 0 {
+		t.Errorf("removeSavedBots() of an unknown bot = %v, %v", removed, err)
+	}
+}
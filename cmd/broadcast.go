@@ -0,0 +1,409 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/auth"
+	"github.com/mmclsntr/lineworks-cli/httpclient"
+)
+
+const DEFAULT_BROADCAST_CONCURRENCY = 4
+
+// Extension of the journal file added to the recipients file path by default
+const BROADCAST_JOURNAL_EXT = ".journal"
+
+// Status of a recipient in the journal
+const (
+	BROADCAST_SENT   = "sent"
+	BROADCAST_FAILED = "failed"
+	// The request was interrupted in flight, so the message may have been delivered
+	BROADCAST_UNKNOWN = "unknown"
+)
+
+// Message to a recipient in a row of the recipients file
+type broadcastItem struct {
+	// Line number in the recipients file
+	Row     int
+	To      botRecipient
+	Content interface{}
+}
+
+// Recipient of the item (ex. user:USER_ID)
+func (item broadcastItem) recipient() string {
+	if item.To.UserID != "" {
+		return "user:" + item.To.UserID
+	}
+	return "channel:" + item.To.ChannelID
+}
+
+// Key to identify the item in the journal. Each row is a message, even if the recipient appears in multiple rows.
+func (item broadcastItem) key() string {
+	return fmt.Sprintf("%d:%s", item.Row, item.recipient())
+}
+
+// Result of a message in the journal (JSON Lines)
+type broadcastJournalEntry struct {
+	Key    string `json:"key"`
+	Row    int    `json:"row"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	Time   string `json:"time"`
+}
+
+type broadcastSummary struct {
+	Total   int `json:"total"`
+	Sent    int `json:"sent"`
+	Failed  int `json:"failed"`
+	Unknown int `json:"unknown"`
+	Skipped int `json:"skipped"`
+}
+
+// Journal of broadcast. Each result is appended and synced, so that it survives interruption.
+type broadcastJournal struct {
+	mu sync.Mutex
+	fp *os.File
+}
+
+func (j *broadcastJournal) write(entry broadcastJournalEntry) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if _, err := j.fp.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	return j.fp.Sync()
+}
+
+// Read the latest status of each recipient in the journal
+func readBroadcastJournal(file string) (map[string]string, error) {
+	fp, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+
+	status := map[string]string{}
+	scanner := bufio.NewScanner(fp)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		entry := broadcastJournalEntry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// The last line may be broken by interruption
+			continue
+		}
+		status[entry.Key] = entry.Status
+	}
+	return status, scanner.Err()
+}
+
+// Rows skipped on resume. Sent ones are skipped, and unknown ones are also skipped unless retryUnknown.
+func resumeSkipped(status map[string]string, retryUnknown bool) map[string]bool {
+	skipped := map[string]bool{}
+	keys := make([]string, 0, len(status))
+	for k := range status {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch status[k] {
+		case BROADCAST_SENT:
+			skipped[k] = true
+		case BROADCAST_UNKNOWN:
+			if retryUnknown {
+				continue
+			}
+			skipped[k] = true
+			fmt.Fprintf(os.Stderr, "%s: skipped, delivery is unknown. Use --retry-unknown to send it again\n", k)
+		}
+	}
+	return skipped
+}
+
+// Read the recipients CSV and render a message for each row.
+// Columns are template variables, and the recipient is userId or channelId column.
+func readBroadcastItems(file string, templateFile string, vars map[string]interface{}) ([]broadcastItem, error) {
+	tmpl, err := parseTemplate(templateFile)
+	if err != nil {
+		return nil, err
+	}
+
+	fp, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer fp.Close()
+	records, err := csv.NewReader(fp).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%s is empty", file)
+	}
+
+	header := records[0]
+	items := []broadcastItem{}
+	for i, record := range records[1:] {
+		row := i + 2
+		data := map[string]interface{}{}
+		for k, v := range vars {
+			data[k] = v
+		}
+		for c, name := range header {
+			if c < len(record) {
+				data[name] = record[c]
+			}
+		}
+
+		to := botRecipient{}
+		if v, ok := data["userId"].(string); ok && v != "" {
+			to.UserID = v
+		} else if v, ok := data["channelId"].(string); ok && v != "" {
+			to.ChannelID = v
+		} else {
+			return nil, fmt.Errorf("row %d: userId or channelId is required", row)
+		}
+
+		content, err := renderMessageContent(tmpl, templateFile, data)
+		if err != nil {
+			return nil, fmt.Errorf("row %d: %w", row, err)
+		}
+		items = append(items, broadcastItem{Row: row, To: to, Content: content})
+	}
+	return items, nil
+}
+
+var botBroadcastCmd = &cobra.Command{
+	Use:   "broadcast",
+	Short: "Send a message to each recipient in a CSV file.",
+	Long: `Send a message rendered from the template to each recipient in a CSV file.
+
+The CSV file must have a header row. The recipient is userId (or channelId) column,
+and all columns are template variables in addition to --var and --vars-file.
+All messages are rendered and validated before sending.
+
+Results are appended to the journal file (default: <recipients>.journal).
+With --resume, rows already sent in the journal are skipped, and failed ones are retried.
+Messages which may have been delivered are recorded as unknown: 5xx responses, and timeouts,
+connection errors and interruption after the request was sent. They are skipped by --resume
+unless --retry-unknown is given.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		bot_name, _ := cmd.Flags().GetString("bot")
+		recipients, _ := cmd.Flags().GetString("recipients")
+		template_file, _ := cmd.Flags().GetString("template")
+		journal_file, _ := cmd.Flags().GetString("journal")
+		resume, _ := cmd.Flags().GetBool("resume")
+		retry_unknown, _ := cmd.Flags().GetBool("retry-unknown")
+		concurrency, _ := cmd.Flags().GetInt("concurrency")
+		rate, _ := cmd.Flags().GetFloat64("rate")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		if concurrency < 1 {
+			return fmt.Errorf("--concurrency must be 1 or more")
+		}
+		if journal_file == "" {
+			journal_file = recipients + BROADCAST_JOURNAL_EXT
+		}
+
+		bot, err := getBotConfigure(profile, bot_name)
+		if err != nil {
+			return err
+		}
+		vars, err := loadTemplateVars(cmd)
+		if err != nil {
+			return err
+		}
+		items, err := readBroadcastItems(recipients, template_file, vars)
+		if err != nil {
+			return err
+		}
+
+		// Rows already sent
+		sent := map[string]bool{}
+		if _, err := os.Stat(journal_file); err == nil && !dryRun {
+			if !resume {
+				return fmt.Errorf("journal %s exists. Use --resume to continue the broadcast, or remove it", journal_file)
+			}
+			status, err := readBroadcastJournal(journal_file)
+			if err != nil {
+				return err
+			}
+			sent = resumeSkipped(status, retry_unknown)
+		}
+
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		if rate > 0 {
+			client.HTTPClient.Transport = httpclient.RateLimit(rate)(client.HTTPClient.Transport)
+		}
+
+		var journal *broadcastJournal
+		if dryRun {
+			// Requests are printed in order
+			concurrency = 1
+		} else {
+			fp, err := os.OpenFile(journal_file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+			if err != nil {
+				return err
+			}
+			defer fp.Close()
+			journal = &broadcastJournal{fp: fp}
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		summary := broadcastSummary{Total: len(items)}
+		var mu sync.Mutex
+		var journalErr error
+		jobs := make(chan broadcastItem)
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for item := range jobs {
+					err := sendBroadcastItem(ctx, client, bot, item, journal)
+					mu.Lock()
+					if err != nil && journalErr == nil {
+						journalErr = err
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+
+		queued := map[string]bool{}
+		for _, item := range items {
+			if sent[item.key()] {
+				summary.Skipped++
+				continue
+			}
+			queued[item.key()] = true
+			select {
+			case jobs <- item:
+			case <-ctx.Done():
+			}
+			if ctx.Err() != nil {
+				break
+			}
+		}
+		close(jobs)
+		wg.Wait()
+
+		if journalErr != nil {
+			return journalErr
+		}
+		if journal != nil {
+			// Count results of this run from the journal
+			status, err := readBroadcastJournal(journal_file)
+			if err != nil {
+				return err
+			}
+			for key := range queued {
+				switch status[key] {
+				case BROADCAST_SENT:
+					summary.Sent++
+				case BROADCAST_FAILED:
+					summary.Failed++
+				case BROADCAST_UNKNOWN:
+					summary.Unknown++
+				}
+			}
+		}
+
+		if outputRequested(cmd) {
+			if err := printOutput(cmd, summary); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Total: %d, Sent: %d, Failed: %d, Unknown: %d, Skipped: %d\n", summary.Total, summary.Sent, summary.Failed, summary.Unknown, summary.Skipped)
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("interrupted. Run again with --resume to continue")
+		}
+		if summary.Failed > 0 {
+			return fmt.Errorf("%d messages failed. Run again with --resume to retry them", summary.Failed)
+		}
+		return nil
+	},
+}
+
+// Send a message and write the result to the journal. Only journal errors are returned.
+func sendBroadcastItem(ctx context.Context, client *api.Client, bot *auth.Bot, item broadcastItem, journal *broadcastJournal) error {
+	if ctx.Err() != nil {
+		return nil
+	}
+	sendCtx, tracker := httpclient.TrackSend(ctx)
+	err := sendBotMessage(sendCtx, client, bot, item.To, item.Content)
+	if isDryRun(err) || journal == nil {
+		return nil
+	}
+	return journal.write(newBroadcastJournalEntry(item, err, tracker.MaybeSent()))
+}
+
+// Journal entry of a send result. maybeSent is true if the request may have reached the server.
+// A failure is unknown if the server may have accepted the message: 5xx responses and
+// errors after the request was sent (ex. timeout, connection reset, interruption).
+// Only 4xx responses and errors before sending are failed, and sent again on resume.
+func newBroadcastJournalEntry(item broadcastItem, err error, maybeSent bool) broadcastJournalEntry {
+	entry := broadcastJournalEntry{
+		Key:    item.key(),
+		Row:    item.Row,
+		Status: BROADCAST_SENT,
+		Time:   time.Now().Format(time.RFC3339),
+	}
+	if err != nil {
+		entry.Status = BROADCAST_FAILED
+		var apiErr *api.Error
+		if errors.As(err, &apiErr) {
+			if apiErr.StatusCode >= 500 {
+				entry.Status = BROADCAST_UNKNOWN
+			}
+		} else if maybeSent {
+			entry.Status = BROADCAST_UNKNOWN
+		}
+		entry.Error = err.Error()
+		fmt.Fprintf(os.Stderr, "row %d (%s): %s\n", item.Row, item.recipient(), err)
+	}
+	return entry
+}
+
+func init() {
+	botCmd.AddCommand(botBroadcastCmd)
+
+	botBroadcastCmd.Flags().StringP("recipients", "", "", "CSV file of recipients with a header row")
+	botBroadcastCmd.MarkFlagRequired("recipients")
+	botBroadcastCmd.Flags().StringP("template", "", "", "Go template file of text or message content")
+	botBroadcastCmd.MarkFlagRequired("template")
+	addTemplateVarFlags(botBroadcastCmd)
+	botBroadcastCmd.Flags().StringP("journal", "", "", "Journal file path. Default is the recipients file path + "+BROADCAST_JOURNAL_EXT)
+	botBroadcastCmd.Flags().BoolP("resume", "", false, "Skip rows already sent in the journal")
+	botBroadcastCmd.Flags().BoolP("retry-unknown", "", false, "With --resume, send again to recipients whose delivery is unknown")
+	botBroadcastCmd.Flags().IntP("concurrency", "", DEFAULT_BROADCAST_CONCURRENCY, "Max concurrent sends")
+	botBroadcastCmd.Flags().Float64P("rate", "", 0, "Max messages per second. 0 means only HTTP client rate limits apply")
+}
@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/auth"
+	"github.com/mmclsntr/lineworks-cli/httpclient"
+)
+
+func TestBroadcastJournal(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "users.csv.journal")
+	fp, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal := &broadcastJournal{fp: fp}
+	entries := []broadcastJournalEntry{
+		{Key: "user:a", Row: 2, Status: BROADCAST_FAILED},
+		{Key: "user:b", Row: 3, Status: BROADCAST_SENT},
+		{Key: "channel:c", Row: 4, Status: BROADCAST_UNKNOWN},
+		// Retried on resume
+		{Key: "user:a", Row: 2, Status: BROADCAST_SENT},
+	}
+	for _, e := range entries {
+		if err := journal.write(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Broken by interruption
+	fp.WriteString(`{"key":"user:d","sta`)
+	fp.Close()
+
+	status, err := readBroadcastJournal(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"user:a":    BROADCAST_SENT,
+		"user:b":    BROADCAST_SENT,
+		"channel:c": BROADCAST_UNKNOWN,
+	}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("status = %v, want %v", status, want)
+	}
+}
+
+func TestResumeSkipped(t *testing.T) {
+	status := map[string]string{
+		"user:sent":    BROADCAST_SENT,
+		"user:failed":  BROADCAST_FAILED,
+		"user:unknown": BROADCAST_UNKNOWN,
+	}
+	cases := []struct {
+		name         string
+		retryUnknown bool
+		want         map[string]bool
+	}{
+		{"unknown is skipped", false, map[string]bool{"user:sent": true, "user:unknown": true}},
+		{"unknown is retried", true, map[string]bool{"user:sent": true}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := resumeSkipped(status, c.retryUnknown); !reflect.DeepEqual(got, c.want) {
+				t.Errorf("resumeSkipped() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestNewBroadcastJournalEntry(t *testing.T) {
+	item := broadcastItem{Row: 5, To: botRecipient{ChannelID: "c1"}}
+	cases := []struct {
+		name      string
+		err       error
+		maybeSent bool
+		status    string
+	}{
+		{"sent", nil, true, BROADCAST_SENT},
+		{"4xx", &api.Error{StatusCode: 400}, true, BROADCAST_FAILED},
+		{"429", &api.Error{StatusCode: 429}, true, BROADCAST_FAILED},
+		{"5xx", fmt.Errorf("send: %w", &api.Error{StatusCode: 502}), true, BROADCAST_UNKNOWN},
+		{"error before sending", errors.New("connection refused"), false, BROADCAST_FAILED},
+		{"token error", errors.New("failed to get a token"), false, BROADCAST_FAILED},
+		{"error after sending", errors.New("connection reset"), true, BROADCAST_UNKNOWN},
+		{"interrupted in flight", context.Canceled, true, BROADCAST_UNKNOWN},
+		{"interrupted before sending", context.Canceled, false, BROADCAST_FAILED},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			entry := newBroadcastJournalEntry(item, c.err, c.maybeSent)
+			if entry.Status != c.status {
+				t.Errorf("status = %s, want %s", entry.Status, c.status)
+			}
+			if entry.Key != item.key() || entry.Row != 5 {
+				t.Errorf("entry = %+v", entry)
+			}
+			if (entry.Error != "") != (c.err != nil) {
+				t.Errorf("error = %q", entry.Error)
+			}
+		})
+	}
+}
+
+func TestSendBroadcastItem(t *testing.T) {
+	cases := []struct {
+		name string
+		// Response status, or one of the failures below
+		status int
+		want   string
+	}{
+		{"sent", http.StatusCreated, BROADCAST_SENT},
+		{"4xx", http.StatusBadRequest, BROADCAST_FAILED},
+		{"5xx", http.StatusBadGateway, BROADCAST_UNKNOWN},
+		{"interrupted", sendInterrupted, BROADCAST_UNKNOWN},
+		{"timed out", sendTimedOut, BROADCAST_UNKNOWN},
+		{"connection refused", sendRefused, BROADCAST_FAILED},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			done := make(chan struct{})
+			defer close(done)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				io.ReadAll(r.Body)
+				switch c.status {
+				case sendInterrupted:
+					cancel()
+					<-r.Context().Done()
+				case sendTimedOut:
+					select {
+					case <-r.Context().Done():
+					case <-done:
+					}
+				default:
+					w.WriteHeader(c.status)
+				}
+			}))
+			defer srv.Close()
+			baseURL := srv.URL
+			if c.status == sendRefused {
+				srv.Close()
+			}
+
+			// Same chain as the CLI: per-attempt timeout and retries
+			retries := 2
+			httpClient, err := httpclient.New(httpclient.Config{Timeout: "200ms", MaxRetries: &retries, RetryWait: "1ms"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := filepath.Join(t.TempDir(), "journal")
+			fp, err := os.Create(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer fp.Close()
+
+			client := &api.Client{HTTPClient: httpClient, BaseURL: baseURL}
+			item := broadcastItem{Row: 2, To: botRecipient{UserID: "u1"}, Content: api.NewTextContent("hi")}
+			if err := sendBroadcastItem(ctx, client, &auth.Bot{BotID: "b1"}, item, &broadcastJournal{fp: fp}); err != nil {
+				t.Fatal(err)
+			}
+			status, err := readBroadcastJournal(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status[item.key()] != c.want {
+				t.Fatalf("status = %s, want %s", status[item.key()], c.want)
+			}
+			// Only failed ones are sent again on resume
+			if skipped := resumeSkipped(status, false)[item.key()]; skipped != (c.want != BROADCAST_FAILED) {
+				t.Errorf("skipped on resume = %v", skipped)
+			}
+		})
+	}
+}
+
+// Failures of TestSendBroadcastItem
+const (
+	sendInterrupted = -iota - 1
+	sendTimedOut
+	sendRefused
+)
+
+func TestReadBroadcastItems(t *testing.T) {
+	dir := t.TempDir()
+	tmpl := filepath.Join(dir, "msg.tmpl")
+	os.WriteFile(tmpl, []byte(`Hi {{.name}}, {{.event}}`), 0600)
+
+	cases := []struct {
+		name string
+		csv  string
+		want []broadcastItem
+		err  bool
+	}{
+		{
+			"users and channels",
+			"userId,channelId,name\nu1,,Alice\n,c1,Team\n",
+			[]broadcastItem{
+				{Row: 2, To: botRecipient{UserID: "u1"}, Content: api.NewTextContent("Hi Alice, release")},
+				{Row: 3, To: botRecipient{ChannelID: "c1"}, Content: api.NewTextContent("Hi Team, release")},
+			},
+			false,
+		},
+		{"column overrides vars", "userId,name,event\nu1,Bob,launch\n", []broadcastItem{{Row: 2, To: botRecipient{UserID: "u1"}, Content: api.NewTextContent("Hi Bob, launch")}}, false},
+		{"no recipient", "userId,name\n,Alice\n", nil, true},
+		{"undefined variable", "userId\nu1\n", nil, true},
+		{"empty file", "", nil, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			file := filepath.Join(dir, "users.csv")
+			os.WriteFile(file, []byte(c.csv), 0600)
+			items, err := readBroadcastItems(file, tmpl, map[string]interface{}{"event": "release"})
+			if c.err {
+				if err == nil {
+					t.Fatal("no error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(items, c.want) {
+				t.Errorf("items = %+v, want %+v", items, c.want)
+			}
+		})
+	}
+}
+
+func TestBroadcastItemKey(t *testing.T) {
+	items := []broadcastItem{
+		{Row: 2, To: botRecipient{UserID: "u1"}},
+		{Row: 3, To: botRecipient{UserID: "u1"}},
+		{Row: 4, To: botRecipient{ChannelID: "u1"}},
+	}
+	want := []string{"2:user:u1", "3:user:u1", "4:channel:u1"}
+	for i, item := range items {
+		if got := item.key(); got != want[i] {
+			t.Errorf("key() = %s, want %s", got, want[i])
+		}
+	}
+}
@@ -1,21 +1,28 @@
 package cmd
 
 import (
-	"encoding/json"
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/cobra"
 
 	"github.com/mmclsntr/lineworks-cli/auth"
+	"github.com/mmclsntr/lineworks-cli/httpclient"
 )
 
 const DEFAULT_ADDR = "127.0.0.1"
 const DEFAULT_PORT = "9876"
 const DEFAULT_PATH = "/oauth/callback"
 
+// Bot Secret for set-bot. It is not taken by a flag, so that it is not shown in the process list or shell history.
+const ENV_BOT_SECRET = "LINEWORKS_BOT_SECRET"
+
 func getClientConfigure(profile string) (*auth.ClientCredential, error) {
 	cred := auth.ClientCredential{}
 
@@ -59,6 +66,57 @@ func getServiceAccountConfigure(profile string) (*auth.ServiceAccount, error) {
 	return s, nil
 }
 
+func getScopePresetsConfigure(profile string) (*auth.ScopePresets, error) {
+	presets := auth.ScopePresets{}
+
+	p, err := presets.ReadConfig(profile)
+	if os.IsNotExist(err) {
+		return &auth.ScopePresets{Presets: map[string]string{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if p.Presets == nil {
+		p.Presets = map[string]string{}
+	}
+
+	return p, nil
+}
+
+func getBotsConfigure(profile string) (*auth.Bots, error) {
+	bots := auth.Bots{}
+
+	b, err := bots.ReadConfig(profile)
+	if os.IsNotExist(err) {
+		return &auth.Bots{Bots: map[string]auth.Bot{}}, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Get a bot by name. The default bot is used if name is empty.
+func getBotConfigure(profile string, name string) (*auth.Bot, error) {
+	bots, err := getBotsConfigure(profile)
+	if err != nil {
+		return nil, err
+	}
+	if len(bots.Bots) == 0 {
+		return nil, errors.New("no bot is configured. Set by 'lineworks configure set-bot'")
+	}
+	return bots.Get(name)
+}
+
+func getHTTPConfigure(profile string) (*httpclient.Config, error) {
+	conf, err := auth.ReadHTTPConfig(profile)
+	if os.IsNotExist(err) {
+		return &httpclient.Config{}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return conf, nil
+}
+
 func setServiceAccountConfigure(profile string, serviceAccountId string, privateKeyFile string) error {
 	privateKeyData, err := ioutil.ReadFile(privateKeyFile)
 	if err != nil {
@@ -87,19 +145,16 @@ var configureGetClientCmd = &cobra.Command{
 	Short: "Get client credentials.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
 		cred, err := getClientConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
 		}
 
-		b, err := json.MarshalIndent(cred, "", "    ")
-		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
-		}
-		fmt.Printf("%s\n", b)
-		return nil
+		return printOutput(cmd, cred)
 	},
 }
 
@@ -115,24 +170,29 @@ var configureSetClientCmd = &cobra.Command{
 		port, _ := cmd.Flags().GetString("port")
 		path, _ := cmd.Flags().GetString("path")
 		domain_id, _ := cmd.Flags().GetString("domain-id")
+		skip_validation, _ := cmd.Flags().GetBool("skip-scope-validation")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		if scopes != "" && !skip_validation {
+			if err := auth.ValidateScopes(scopes); err != nil {
+				return err
+			}
+		}
 
 		redirect_url := fmt.Sprintf("http://%s:%s%s", addr, port, path)
-		setClientConfigure(profile, client_id, client_secret, scopes, redirect_url, addr, port, path, domain_id)
+		if err := setClientConfigure(profile, client_id, client_secret, scopes, redirect_url, addr, port, path, domain_id); err != nil {
+			return err
+		}
 
 		// View
 		cred, err := getClientConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
 		}
 
-		b, err := json.MarshalIndent(cred, "", "    ")
-		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
-		}
-		fmt.Printf("%s\n", b)
-		return nil
+		return printOutput(cmd, cred)
 	},
 }
 
@@ -141,10 +201,16 @@ var configureGetRedirectUrlCmd = &cobra.Command{
 	Short: "Get redirect url.",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
 		cred, err := getClientConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
+		}
+		if outputRequested(cmd) {
+			return printOutput(cmd, map[string]string{"redirect_url": cred.GetRedirectUrl()})
 		}
 		fmt.Printf("%s\n", cred.GetRedirectUrl())
 		return nil
@@ -157,18 +223,14 @@ var configureGetServiceAccountCmd = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profile, _ := cmd.Flags().GetString("profile")
 
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
 		sa, err := getServiceAccountConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
-		}
-		b, err := json.MarshalIndent(sa, "", "    ")
-		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
 		}
-		fmt.Printf("%s\n", b)
-		return nil
+		return printOutput(cmd, sa)
 	},
 }
 
@@ -180,21 +242,282 @@ var configureSetServiceAccountCmd = &cobra.Command{
 		serviceAccountId, _ := cmd.Flags().GetString("service-account-id")
 		privateKeyFile, _ := cmd.Flags().GetString("private-key-file")
 
-		setServiceAccountConfigure(profile, serviceAccountId, privateKeyFile)
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		if err := setServiceAccountConfigure(profile, serviceAccountId, privateKeyFile); err != nil {
+			return err
+		}
 
 		// View
 		sa, err := getServiceAccountConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
+		}
+		return printOutput(cmd, sa)
+	},
+}
+
+var configureSetScopePresetCmd = &cobra.Command{
+	Use:   "set-scope-preset",
+	Short: "Set a named scope preset.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		name, _ := cmd.Flags().GetString("name")
+		scopes, _ := cmd.Flags().GetString("scopes")
+		skip_validation, _ := cmd.Flags().GetBool("skip-scope-validation")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		if !skip_validation {
+			if err := auth.ValidateScopes(scopes); err != nil {
+				return err
+			}
 		}
-		b, err := json.MarshalIndent(sa, "", "    ")
+
+		presets, err := getScopePresetsConfigure(profile)
 		if err != nil {
-			fmt.Printf("%s", err)
-			return nil
+			return err
 		}
-		fmt.Printf("%s\n", b)
-		return nil
+		presets.Presets[name] = auth.NormalizeScopes(scopes)
+		if err := presets.WriteConfig(profile); err != nil {
+			return err
+		}
+
+		return printOutput(cmd, presets.Presets)
+	},
+}
+
+var configureListScopePresetsCmd = &cobra.Command{
+	Use:   "list-scope-presets",
+	Short: "List scope presets. Includes built-in presets.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		presets, err := getScopePresetsConfigure(profile)
+		if err != nil {
+			return err
+		}
+
+		all := map[string]string{}
+		for k, v := range auth.BuiltinScopePresets {
+			all[k] = v
+		}
+		for k, v := range presets.Presets {
+			all[k] = v
+		}
+
+		return printOutput(cmd, all)
+	},
+}
+
+// Read Bot Secret from the first line of r if fromStdin, or else from the environment variable.
+// ok is false if the secret is not given.
+func readBotSecret(r io.Reader, fromStdin bool) (secret string, ok bool, err error) {
+	if !fromStdin {
+		secret, ok = os.LookupEnv(ENV_BOT_SECRET)
+		return secret, ok, nil
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", false, err
+	}
+	secret = strings.TrimRight(line, "\r\n")
+	if secret == "" {
+		return "", false, errors.New("Bot Secret is empty")
+	}
+	return secret, true, nil
+}
+
+var configureSetBotCmd = &cobra.Command{
+	Use:   "set-bot",
+	Short: "Set a named bot. Only given settings are updated for an existing bot.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		name, _ := cmd.Flags().GetString("name")
+		bot_id, _ := cmd.Flags().GetString("bot-id")
+		bot_secret_stdin, _ := cmd.Flags().GetBool("bot-secret-stdin")
+		default_channel, _ := cmd.Flags().GetString("default-channel")
+		is_default, _ := cmd.Flags().GetBool("default")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_secret, has_secret, err := readBotSecret(os.Stdin, bot_secret_stdin)
+		if err != nil {
+			return err
+		}
+		bots, err := getBotsConfigure(profile)
+		if err != nil {
+			return err
+		}
+		bot, exists := bots.Bots[name]
+		if !exists && bot_id == "" {
+			return errors.New("--bot-id is required for a new bot")
+		}
+		if cmd.Flags().Changed("bot-id") {
+			bot.BotID = bot_id
+		}
+		if has_secret {
+			bot.BotSecret = bot_secret
+		}
+		if cmd.Flags().Changed("default-channel") {
+			bot.DefaultChannel = default_channel
+		}
+		bots.Bots[name] = bot
+		if is_default {
+			bots.Default = name
+		}
+		if err := bots.WriteConfig(profile); err != nil {
+			return err
+		}
+
+		return printOutput(cmd, bot)
+	},
+}
+
+var configureGetBotCmd = &cobra.Command{
+	Use:   "get-bot",
+	Short: "Get a bot. The default bot is shown if --name is not set.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		name, _ := cmd.Flags().GetString("name")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot, err := getBotConfigure(profile, name)
+		if err != nil {
+			return err
+		}
+		return printOutput(cmd, bot)
+	},
+}
+
+type botListItem struct {
+	Name           string `json:"name"`
+	BotID          string `json:"bot_id"`
+	DefaultChannel string `json:"default_channel"`
+	Default        bool   `json:"default"`
+}
+
+var configureListBotsCmd = &cobra.Command{
+	Use:   "list-bots",
+	Short: "List bots. Bot secrets are not shown.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bots, err := getBotsConfigure(profile)
+		if err != nil {
+			return err
+		}
+
+		items := []botListItem{}
+		for _, name := range bots.Names() {
+			bot := bots.Bots[name]
+			items = append(items, botListItem{
+				Name:           name,
+				BotID:          bot.BotID,
+				DefaultChannel: bot.DefaultChannel,
+				Default:        name == bots.Default,
+			})
+		}
+		return printOutput(cmd, items)
+	},
+}
+
+var configureGetHTTPCmd = &cobra.Command{
+	Use:   "get-http",
+	Short: "Get HTTP client settings.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		conf, err := getHTTPConfigure(profile)
+		if err != nil {
+			return err
+		}
+
+		return printOutput(cmd, conf)
+	},
+}
+
+var configureSetHTTPCmd = &cobra.Command{
+	Use:   "set-http",
+	Short: "Set HTTP client settings. Only given settings are updated.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		conf, err := getHTTPConfigure(profile)
+		if err != nil {
+			return err
+		}
+
+		if cmd.Flags().Changed("timeout") {
+			conf.Timeout, _ = cmd.Flags().GetString("timeout")
+		}
+		if cmd.Flags().Changed("proxy") {
+			conf.Proxy, _ = cmd.Flags().GetString("proxy")
+		}
+		if cmd.Flags().Changed("ca-bundle") {
+			conf.CABundle, _ = cmd.Flags().GetString("ca-bundle")
+		}
+		if cmd.Flags().Changed("tls-min-version") {
+			conf.TLSMinVersion, _ = cmd.Flags().GetString("tls-min-version")
+		}
+		if cmd.Flags().Changed("max-retries") {
+			max_retries, _ := cmd.Flags().GetInt("max-retries")
+			conf.MaxRetries = &max_retries
+		}
+		if cmd.Flags().Changed("retry-wait") {
+			conf.RetryWait, _ = cmd.Flags().GetString("retry-wait")
+		}
+		if cmd.Flags().Changed("rate-limit") {
+			rate_limits, _ := cmd.Flags().GetStringArray("rate-limit")
+			if conf.RateLimits == nil {
+				conf.RateLimits = map[string]float64{}
+			}
+			for _, r := range rate_limits {
+				i := strings.LastIndex(r, "=")
+				if i < 0 {
+					return fmt.Errorf("invalid rate limit '%s'. Must be PATTERN=RATE format", r)
+				}
+				rate, err := strconv.ParseFloat(r[i+1:], 64)
+				if err != nil {
+					return fmt.Errorf("invalid rate limit '%s'. %s", r, err)
+				}
+				// Rate 0 removes the limit
+				if rate == 0 {
+					delete(conf.RateLimits, r[:i])
+				} else {
+					conf.RateLimits[r[:i]] = rate
+				}
+			}
+		}
+		if cmd.Flags().Changed("max-concurrency") {
+			conf.MaxConcurrency, _ = cmd.Flags().GetInt("max-concurrency")
+		}
+
+		if err := conf.Validate(); err != nil {
+			return err
+		}
+		if err := auth.WriteHTTPConfig(profile, conf); err != nil {
+			return err
+		}
+
+		return printOutput(cmd, conf)
 	},
 }
 
@@ -205,6 +528,13 @@ func init() {
 	configureCmd.AddCommand(configureGetRedirectUrlCmd)
 	configureCmd.AddCommand(configureGetServiceAccountCmd)
 	configureCmd.AddCommand(configureSetServiceAccountCmd)
+	configureCmd.AddCommand(configureSetScopePresetCmd)
+	configureCmd.AddCommand(configureListScopePresetsCmd)
+	configureCmd.AddCommand(configureSetBotCmd)
+	configureCmd.AddCommand(configureGetBotCmd)
+	configureCmd.AddCommand(configureListBotsCmd)
+	configureCmd.AddCommand(configureGetHTTPCmd)
+	configureCmd.AddCommand(configureSetHTTPCmd)
 
 	configureCmd.PersistentFlags().StringP("profile", "", "", "Profile name")
 	configureCmd.MarkPersistentFlagRequired("profile")
@@ -218,9 +548,34 @@ func init() {
 	configureSetClientCmd.Flags().StringP("port", "", DEFAULT_PORT, "Listening port of callback server")
 	configureSetClientCmd.Flags().StringP("path", "", DEFAULT_PATH, "URL path of callback server")
 	configureSetClientCmd.Flags().StringP("domain-id", "", "", "Domain ID")
+	configureSetClientCmd.Flags().BoolP("skip-scope-validation", "", false, "Skip validation of scopes against known scopes")
 
 	configureSetServiceAccountCmd.Flags().StringP("service-account-id", "", "", "Service Account ID")
 	configureSetClientCmd.MarkFlagRequired("service-account-id")
 	configureSetServiceAccountCmd.Flags().StringP("private-key-file", "", "", "Private Key file path")
 	configureSetClientCmd.MarkFlagRequired("private-key-file")
+
+	configureSetScopePresetCmd.Flags().StringP("name", "", "", "Preset name")
+	configureSetScopePresetCmd.MarkFlagRequired("name")
+	configureSetScopePresetCmd.Flags().StringP("scopes", "", "", "Scopes. Must be comma-delimited format (ex. bot,user.read,board)")
+	configureSetScopePresetCmd.MarkFlagRequired("scopes")
+	configureSetScopePresetCmd.Flags().BoolP("skip-scope-validation", "", false, "Skip validation of scopes against known scopes")
+
+	configureSetBotCmd.Flags().StringP("name", "", "", "Bot name in the profile")
+	configureSetBotCmd.MarkFlagRequired("name")
+	configureSetBotCmd.Flags().StringP("bot-id", "", "", "Bot ID")
+	configureSetBotCmd.Flags().BoolP("bot-secret-stdin", "", false, "Read Bot Secret from the first line of stdin. Otherwise it is read from $"+ENV_BOT_SECRET+" if set")
+	configureSetBotCmd.Flags().StringP("default-channel", "", "", "Channel ID to send messages by default")
+	configureSetBotCmd.Flags().BoolP("default", "", false, "Use the bot when no bot name is given")
+
+	configureGetBotCmd.Flags().StringP("name", "", "", "Bot name in the profile")
+
+	configureSetHTTPCmd.Flags().StringP("timeout", "", "", "Request timeout (ex. 30s)")
+	configureSetHTTPCmd.Flags().StringP("proxy", "", "", "HTTP(S) proxy URL (ex. http://proxy.example.com:8080)")
+	configureSetHTTPCmd.Flags().StringP("ca-bundle", "", "", "PEM file path of additional CA certificates")
+	configureSetHTTPCmd.Flags().StringP("tls-min-version", "", "", "Minimum TLS version. 1.0, 1.1, 1.2 or 1.3")
+	configureSetHTTPCmd.Flags().IntP("max-retries", "", httpclient.DEFAULT_MAX_RETRIES, "Max retry count on 429, 5xx and connection errors")
+	configureSetHTTPCmd.Flags().StringP("retry-wait", "", "", "Initial wait of exponential backoff (ex. 500ms)")
+	configureSetHTTPCmd.Flags().StringArrayP("rate-limit", "", []string{}, "Requests per second for an endpoint in '[METHOD ]PATH=RATE' format. * matches a path segment. RATE 0 removes the limit")
+	configureSetHTTPCmd.Flags().IntP("max-concurrency", "", 0, "Max concurrent requests. 0 means no limit")
 }
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadBotSecret(t *testing.T) {
+	cases := []struct {
+		name      string
+		env       string
+		setEnv    bool
+		stdin     string
+		fromStdin bool
+		want      string
+		ok        bool
+		err       bool
+	}{
+		{"not given", "", false, "", false, "", false, false},
+		{"environment variable", "env-secret", true, "", false, "env-secret", true, false},
+		{"stdin", "env-secret", true, "stdin-secret\n", true, "stdin-secret", true, false},
+		{"stdin only first line", "", false, "stdin-secret\r\nrest\n", true, "stdin-secret", true, false},
+		{"stdin without newline", "", false, "stdin-secret", true, "stdin-secret", true, false},
+		{"empty stdin", "", false, "\n", true, "", false, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			t.Setenv(ENV_BOT_SECRET, c.env)
+			if !c.setEnv {
+				os.Unsetenv(ENV_BOT_SECRET)
+			}
+			got, ok, err := readBotSecret(strings.NewReader(c.stdin), c.fromStdin)
+			if (err != nil) != c.err {
+				t.Fatalf("err = %v, want error %v", err, c.err)
+			}
+			if got != c.want || ok != c.ok {
+				t.Errorf("readBotSecret() = %q, %v, want %q, %v", got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
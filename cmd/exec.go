@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/httpclient"
+)
+
+const ENV_API_BASE_URL = api.BASE_URL_ENV_NAME
+const ENV_DOMAIN_ID = "LINEWORKS_DOMAIN_ID"
+
+// Run the command with the access token in environment variables.
+// Returns exit code of the command.
+func execWithToken(profile string, scopes string, name string, args []string) (int, error) {
+	if dryRun {
+		// The token is not obtained, and the command is not started
+		cred, err := getClientConfigure(profile)
+		if err != nil {
+			return 1, err
+		}
+		fmt.Println(dryRunCommand(cred.DomainID, name, args))
+		return 0, nil
+	}
+
+	token, err := getValidToken(profile, scopes)
+	if err != nil {
+		return 1, err
+	}
+	cred, err := getClientConfigure(profile)
+	if err != nil {
+		return 1, err
+	}
+
+	child := exec.Command(name, args...)
+	child.Stdin = os.Stdin
+	child.Stdout = os.Stdout
+	child.Stderr = os.Stderr
+	child.Env = append(os.Environ(),
+		fmt.Sprintf("%s=%s", ENV_ACCESS_TOKEN, token.AccessToken),
+		fmt.Sprintf("%s=%s", ENV_API_BASE_URL, api.GetBaseURL()),
+		fmt.Sprintf("%s=%s", ENV_DOMAIN_ID, cred.DomainID),
+	)
+
+	if err := child.Start(); err != nil {
+		return 127, err
+	}
+
+	// Forward signals to the child
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGQUIT)
+	defer signal.Stop(sigs)
+	go func() {
+		for sig := range sigs {
+			child.Process.Signal(sig)
+		}
+	}()
+
+	err = child.Wait()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+			return 128 + int(status.Signal()), nil
+		}
+		return exitErr.ExitCode(), nil
+	} else if err != nil {
+		return 1, err
+	}
+	return 0, nil
+}
+
+// Command line printed by --dry-run. The access token is redacted.
+func dryRunCommand(domainID string, name string, args []string) string {
+	words := []string{
+		fmt.Sprintf("%s=%s", ENV_ACCESS_TOKEN, httpclient.REDACTED),
+		fmt.Sprintf("%s=%s", ENV_API_BASE_URL, quoteArg(api.GetBaseURL())),
+		fmt.Sprintf("%s=%s", ENV_DOMAIN_ID, quoteArg(domainID)),
+		quoteArg(name),
+	}
+	for _, a := range args {
+		words = append(words, quoteArg(a))
+	}
+	return strings.Join(words, " ")
+}
+
+// Quote an argument for shells if needed
+func quoteArg(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:=@%+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var execCmd = &cobra.Command{
+	Use:   "exec -- command [args...]",
+	Short: "Run a command with an access token in environment variables.",
+	Long: fmt.Sprintf(`Run a command with a valid access token. The token is renewed if needed.
+
+The following environment variables are set for the command.
+  %s : Access token
+  %s : API base URL
+  %s : Domain ID
+
+With --dry-run, the command line is printed without obtaining the token or running the command.`, ENV_ACCESS_TOKEN, ENV_API_BASE_URL, ENV_DOMAIN_ID),
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		scopes, _ := cmd.Flags().GetString("scopes")
+
+		code, err := execWithToken(profile, scopes, args[0], args[1:])
+		if isDryRun(err) {
+			return nil
+		} else if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+		closeHTTPRecorders()
+		os.Exit(code)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+
+	execCmd.Flags().SetInterspersed(false)
+	execCmd.Flags().StringP("profile", "", "", "Profile name")
+	execCmd.MarkFlagRequired("profile")
+	execCmd.Flags().StringP("scopes", "", "", "Scopes which the token must cover")
+}
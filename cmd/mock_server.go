@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/auth"
+	"github.com/mmclsntr/lineworks-cli/mock"
+)
+
+const DEFAULT_MOCK_SERVER_PORT = "9080"
+
+var mockServerCmd = &cobra.Command{
+	Use:   "mock-server",
+	Short: "Run a local mock LINE WORKS server.",
+	Long: `Run a local mock LINE WORKS server for offline testing.
+
+It serves the OAuth authorize/token endpoints and users, bots, bot domains, bot messages, bot attachments and rich menus APIs with in-memory state.
+Fixtures are loaded from the dir of --fixtures (users.json, bots.json, routes.json).
+The current state is available on /_mock/state.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		addr, _ := cmd.Flags().GetString("addr")
+		port, _ := cmd.Flags().GetString("port")
+		fixtures, _ := cmd.Flags().GetString("fixtures")
+		require_auth, _ := cmd.Flags().GetBool("require-auth")
+
+		state, routes, err := mock.LoadFixtures(fixtures)
+		if err != nil {
+			fmt.Printf("%s", err)
+			return nil
+		}
+		srv := mock.NewServer(state, routes)
+		srv.RequireAuth = require_auth
+
+		listener, err := net.Listen("tcp", fmt.Sprintf("%s:%s", addr, port))
+		if err != nil {
+			fmt.Printf("%s", err)
+			return nil
+		}
+
+		baseURL := fmt.Sprintf("http://%s", listener.Addr())
+		fmt.Printf("Listening on %s\n", listener.Addr())
+		fmt.Printf("export %s=%s\n", auth.AUTH_BASE_URL_ENV_NAME, baseURL)
+		fmt.Printf("export %s=%s/v1.0\n", api.BASE_URL_ENV_NAME, baseURL)
+
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+
+		httpSrv := &http.Server{Handler: srv}
+		go func() {
+			<-ctx.Done()
+			httpSrv.Shutdown(context.Background())
+		}()
+		if err := httpSrv.Serve(listener); err != http.ErrServerClosed {
+			fmt.Printf("%s", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(mockServerCmd)
+
+	mockServerCmd.Flags().StringP("addr", "", DEFAULT_ADDR, "Listening address")
+	mockServerCmd.Flags().StringP("port", "", DEFAULT_MOCK_SERVER_PORT, "Listening port")
+	mockServerCmd.Flags().StringP("fixtures", "", "", "Dir of fixture files")
+	mockServerCmd.Flags().BoolP("require-auth", "", false, "Reject API requests without an access token issued by the mock server")
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jmespath/go-jmespath"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var outputFormats = []string{"json", "yaml", "table", "csv", "tsv"}
+
+func validateOutputFormat(format string) error {
+	for _, f := range outputFormats {
+		if f == format {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown output format '%s'. Must be one of %s", format, strings.Join(outputFormats, ", "))
+}
+
+// Check --output or --query is given explicitly.
+// Commands which print plain text by default use it to switch to formatted output.
+func outputRequested(cmd *cobra.Command) bool {
+	query, _ := cmd.Flags().GetString("query")
+	return cmd.Flags().Changed("output") || query != ""
+}
+
+// Print the value in the format of --output, after applying --query
+func printOutput(cmd *cobra.Command, v interface{}) error {
+	format, _ := cmd.Flags().GetString("output")
+	query, _ := cmd.Flags().GetString("query")
+	return writeOutput(os.Stdout, format, query, v)
+}
+
+// Write the value in the format, after applying the query
+func writeOutput(out io.Writer, format string, query string, v interface{}) error {
+	data, err := toGeneric(v)
+	if err != nil {
+		return err
+	}
+	if query != "" {
+		// jmespath compares only float64 numbers
+		data, err = jmespath.Search(query, normalizeNumbers(data, true))
+		if err != nil {
+			return fmt.Errorf("invalid query: %w", err)
+		}
+		data = integerFloats(data)
+	} else {
+		data = normalizeNumbers(data, false)
+	}
+
+	switch format {
+	case "", "json":
+		b, err := json.MarshalIndent(data, "", "    ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s\n", b)
+	case "yaml":
+		b, err := yaml.Marshal(data)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s", b)
+	case "table":
+		header, rows := toRows(data)
+		w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+		if header != nil {
+			upper := make([]string, len(header))
+			for i, h := range header {
+				upper[i] = strings.ToUpper(h)
+			}
+			fmt.Fprintln(w, strings.Join(upper, "\t"))
+		}
+		for _, row := range rows {
+			fmt.Fprintln(w, strings.Join(row, "\t"))
+		}
+		w.Flush()
+	case "csv", "tsv":
+		header, rows := toRows(data)
+		w := csv.NewWriter(out)
+		if format == "tsv" {
+			w.Comma = '\t'
+		}
+		if header != nil {
+			w.Write(header)
+		}
+		w.WriteAll(rows)
+	default:
+		return validateOutputFormat(format)
+	}
+	return nil
+}
+
+// Convert to JSON-compatible generic value, so that json tags are respected
+func toGeneric(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var data interface{}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// json.Number is not understood by jmespath and yaml, so convert to int64 or float64.
+// All numbers are converted to float64 if floats.
+func normalizeNumbers(v interface{}, floats bool) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil && !floats {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = normalizeNumbers(e, floats)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = normalizeNumbers(e, floats)
+		}
+	}
+	return v
+}
+
+// Convert float64 of integers back to int64, so that they are not printed in exponent form
+func integerFloats(v interface{}) interface{} {
+	switch t := v.(type) {
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<53 {
+			return int64(t)
+		}
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = integerFloats(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = integerFloats(e)
+		}
+	}
+	return v
+}
+
+// Convert to rows for table, csv and tsv.
+// A list of objects is a row per object, an object is a row, and scalars are a single column.
+func toRows(data interface{}) ([]string, [][]string) {
+	items, ok := data.([]interface{})
+	if !ok {
+		items = []interface{}{data}
+	}
+
+	// Columns are union of object keys
+	columnSet := map[string]bool{}
+	for _, item := range items {
+		if m, ok := item.(map[string]interface{}); ok {
+			for k := range m {
+				columnSet[k] = true
+			}
+		}
+	}
+	if len(columnSet) == 0 {
+		rows := [][]string{}
+		for _, item := range items {
+			rows = append(rows, []string{cellString(item)})
+		}
+		return nil, rows
+	}
+	columns := []string{}
+	for k := range columnSet {
+		columns = append(columns, k)
+	}
+	sort.Strings(columns)
+
+	rows := [][]string{}
+	for _, item := range items {
+		m, _ := item.(map[string]interface{})
+		row := make([]string, len(columns))
+		for i, c := range columns {
+			row[i] = cellString(m[c])
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows
+}
+
+func cellString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case map[string]interface{}, []interface{}:
+		b, _ := json.Marshal(t)
+		return string(b)
+	}
+	return fmt.Sprintf("%v", v)
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestWriteOutput(t *testing.T) {
+	type user struct {
+		ID    int64  `json:"userId"`
+		Email string `json:"email"`
+		Org   string `json:"org,omitempty"`
+	}
+	users := []user{{ID: 1234567890123, Email: "a@example.com", Org: "dev"}, {ID: 2, Email: "b,c@example.com"}}
+
+	cases := []struct {
+		name   string
+		format string
+		query  string
+		v      interface{}
+		want   string
+		err    string
+	}{
+		{"json", "json", "", users[1], "{\n    \"email\": \"b,c@example.com\",\n    \"userId\": 2\n}\n", ""},
+		{"default is json", "", "", []int{1}, "[\n    1\n]\n", ""},
+		{"yaml", "yaml", "", users, "- email: a@example.com\n  org: dev\n  userId: 1234567890123\n- email: b,c@example.com\n  userId: 2\n", ""},
+		{"table", "table", "", users, "EMAIL            ORG  USERID\na@example.com    dev  1234567890123\nb,c@example.com       2\n", ""},
+		{"csv", "csv", "", users, "email,org,userId\na@example.com,dev,1234567890123\n\"b,c@example.com\",,2\n", ""},
+		{"tsv", "tsv", "", users, "email\torg\tuserId\na@example.com\tdev\t1234567890123\nb,c@example.com\t\t2\n", ""},
+		{"scalars in table", "table", "", []string{"a", "b"}, "a\nb\n", ""},
+		{"nested value in csv", "csv", "", map[string]interface{}{"tags": []string{"x"}}, "tags\n\"[\"\"x\"\"]\"\n", ""},
+		{"query", "json", "[].email", users, "[\n    \"a@example.com\",\n    \"b,c@example.com\"\n]\n", ""},
+		{"query with number", "csv", "[?userId > `100`].{id: userId}", users, "id\n1234567890123\n", ""},
+		{"query keeps fractions", "json", "ratio", map[string]float64{"ratio": 0.5}, "0.5\n", ""},
+		{"invalid query", "json", "[", users, "", "invalid query"},
+		{"unknown format", "xml", "", users, "", "unknown output format 'xml'"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var out bytes.Buffer
+			err := writeOutput(&out, c.format, c.query, c.v)
+			if c.err != "" {
+				if err == nil || !strings.Contains(err.Error(), c.err) {
+					t.Fatalf("writeOutput() = %v, want %s", err, c.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != c.want {
+				t.Errorf("output = %q, want %q", out.String(), c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+	"github.com/mmclsntr/lineworks-cli/auth"
+)
+
+const DEFAULT_PIPE_INTERVAL = 2 * time.Second
+const DEFAULT_PIPE_MAX_LINES = 50
+
+// Time to send buffered lines on interrupt
+const PIPE_FLUSH_TIMEOUT = 10 * time.Second
+
+// Backoff on throttling and server errors
+const PIPE_BACKOFF_MIN = time.Second
+const PIPE_BACKOFF_MAX = time.Minute
+
+// Read lines from r and send them to the channel. The channel is closed on EOF.
+func readLines(r io.Reader, lines chan<- string, errs chan<- error) {
+	defer close(lines)
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+		if line != "" || (err == nil) {
+			lines <- line
+		}
+		if err != nil {
+			if err != io.EOF {
+				errs <- err
+			}
+			return
+		}
+	}
+}
+
+// Check the error is temporary, and sending should be retried later
+func isThrottled(err error) bool {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	if errors.Is(err, context.Canceled) {
+		return false
+	}
+	// Transport errors. Other errors (ex. token, config and validation errors) are not recovered by retrying.
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) || errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)
+}
+
+// Send text split into messages within the length limit.
+// On throttling, it backs off and retries until ctx is done. Other errors are returned.
+func sendPipeText(ctx context.Context, client *api.Client, bot *auth.Bot, to botRecipient, text string) error {
+	for _, chunk := range api.SplitText(text, api.MAX_TEXT_LENGTH) {
+		wait := PIPE_BACKOFF_MIN
+		for {
+			err := sendBotMessage(ctx, client, bot, to, api.NewTextContent(chunk))
+			if err == nil || isDryRun(err) {
+				break
+			}
+			if !isThrottled(err) || ctx.Err() != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "send failed, retrying in %s: %s\n", wait, err)
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			wait *= 2
+			if wait > PIPE_BACKOFF_MAX {
+				wait = PIPE_BACKOFF_MAX
+			}
+		}
+	}
+	return nil
+}
+
+var botPipeCmd = &cobra.Command{
+	Use:   "pipe",
+	Short: "Send lines from stdin to a user or a channel.",
+	Long: `Send lines from stdin to a user or a channel by the bot.
+
+Lines are batched into a message within --interval after the first line, up to --max-lines.
+Messages over the text length limit are split. On throttling, sending is retried with backoff.
+
+  tail -f app.log | grep ERROR | lineworks bot pipe --channel CHANNEL_ID --profile PROFILE`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		bot_name, _ := cmd.Flags().GetString("bot")
+		interval, _ := cmd.Flags().GetDuration("interval")
+		max_lines, _ := cmd.Flags().GetInt("max-lines")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot, err := getBotConfigure(profile, bot_name)
+		if err != nil {
+			return err
+		}
+		to, err := getBotRecipient(cmd, bot)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+
+		ctx, stop := signal.NotifyContext(cmd.Context(), os.Interrupt)
+		defer stop()
+
+		lines := make(chan string)
+		readErrs := make(chan error, 1)
+		go readLines(os.Stdin, lines, readErrs)
+
+		batch := []string{}
+		var timer <-chan time.Time
+		flush := func(ctx context.Context) error {
+			if len(batch) == 0 {
+				return nil
+			}
+			text := strings.Join(batch, "\n")
+			batch = batch[:0]
+			timer = nil
+			if strings.TrimSpace(text) == "" {
+				return nil
+			}
+			err := sendPipeText(ctx, client, bot, to, text)
+			if err != nil && !isThrottled(err) {
+				// Bad messages are dropped, and following lines are sent
+				fmt.Fprintf(os.Stderr, "failed to send message: %s\n", err)
+				return nil
+			}
+			return err
+		}
+
+		for {
+			select {
+			case line, ok := <-lines:
+				if !ok {
+					if err := flush(ctx); err != nil {
+						return err
+					}
+					select {
+					case err := <-readErrs:
+						return err
+					default:
+						return nil
+					}
+				}
+				batch = append(batch, line)
+				if timer == nil {
+					timer = time.After(interval)
+				}
+				if max_lines > 0 && len(batch) >= max_lines {
+					if err := flush(ctx); err != nil {
+						return err
+					}
+				}
+			case <-timer:
+				if err := flush(ctx); err != nil {
+					return err
+				}
+			case <-ctx.Done():
+				// Send buffered lines before exit
+				flushCtx, cancel := context.WithTimeout(context.Background(), PIPE_FLUSH_TIMEOUT)
+				defer cancel()
+				return flush(flushCtx)
+			}
+		}
+	},
+}
+
+func init() {
+	botCmd.AddCommand(botPipeCmd)
+
+	botPipeCmd.Flags().StringP("user", "", "", "User ID to send to")
+	botPipeCmd.Flags().StringP("channel", "", "", "Channel ID to send to")
+	botPipeCmd.Flags().DurationP("interval", "", DEFAULT_PIPE_INTERVAL, "Time window to batch lines into a message")
+	botPipeCmd.Flags().IntP("max-lines", "", DEFAULT_PIPE_MAX_LINES, "Max lines in a message. 0 means no limit")
+}
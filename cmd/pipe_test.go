@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+)
+
+func TestIsThrottled(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"429", &api.Error{StatusCode: 429}, true},
+		{"503", &api.Error{StatusCode: 503}, true},
+		{"wrapped 500", fmt.Errorf("send: %w", &api.Error{StatusCode: 500}), true},
+		{"400", &api.Error{StatusCode: 400}, false},
+		{"403", &api.Error{StatusCode: 403}, false},
+		{"connection refused", &url.Error{Op: "Post", URL: "http://x", Err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}}, true},
+		{"unexpected EOF", &url.Error{Op: "Post", URL: "http://x", Err: io.ErrUnexpectedEOF}, true},
+		{"canceled", &url.Error{Op: "Post", URL: "http://x", Err: context.Canceled}, false},
+		{"token error", errors.New("failed to get a token"), false},
+		{"validation error", api.ValidateText(""), false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := isThrottled(c.err); got != c.want {
+				t.Errorf("isThrottled(%v) = %v, want %v", c.err, got, c.want)
+			}
+		})
+	}
+}
+
+func TestReadLines(t *testing.T) {
+	cases := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"lines", "a\nb\n", []string{"a", "b"}},
+		{"no trailing newline", "a\nb", []string{"a", "b"}},
+		{"CRLF", "a\r\nb\r\n", []string{"a", "b"}},
+		{"empty lines are kept", "a\n\nb\n", []string{"a", "", "b"}},
+		{"empty input", "", []string{}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			lines := make(chan string, 10)
+			errs := make(chan error, 1)
+			readLines(strings.NewReader(c.input), lines, errs)
+			got := []string{}
+			for line := range lines {
+				got = append(got, line)
+			}
+			if !reflect.DeepEqual(got, c.want) {
+				t.Errorf("lines = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,283 @@
+package cmd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/api"
+)
+
+// Read a rich menu image and its size. Only PNG and JPEG are supported.
+func readRichmenuImage(file string) ([]byte, *api.ImageSize, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, nil, err
+	}
+	conf, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%s is not a PNG or JPEG image: %w", file, err)
+	}
+	if format != "png" && format != "jpeg" {
+		return nil, nil, fmt.Errorf("%s is %s. Must be PNG or JPEG", file, format)
+	}
+	return data, &api.ImageSize{Width: conf.Width, Height: conf.Height}, nil
+}
+
+// Upload the image and set it to the rich menu
+func uploadRichmenuImage(cmd *cobra.Command, client *api.Client, bot_id string, richmenu_id string, file string, data []byte) error {
+	fileID, err := client.UploadFile(cmd.Context(), bot_id, file, data, newUploadProgress(filepath.Base(file)))
+	if err != nil {
+		return err
+	}
+	return client.SetRichmenuImage(cmd.Context(), bot_id, richmenu_id, fileID)
+}
+
+var botRichmenuCmd = &cobra.Command{
+	Use:   "richmenu",
+	Short: "Manage rich menus of a bot.",
+	Long: `Manage rich menus of the bot of --bot.
+
+Rich menus are defined by a JSON or YAML file of richmenuName, size and areas.
+Area bounds are validated against the menu size and the image size before requests.`,
+}
+
+var botRichmenuCreateCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Create a rich menu from a JSON or YAML file.",
+	Long: `Create a rich menu from a JSON or YAML file.
+
+With --image, the size of the image must match the menu size, and it is uploaded as the menu image.
+
+  lineworks bot richmenu create --input menu.yaml --image menu.png --profile PROFILE`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		input, _ := cmd.Flags().GetString("input")
+		image_file, _ := cmd.Flags().GetString("image")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		menu, err := readObjectFile(input)
+		if err != nil {
+			return err
+		}
+		var data []byte
+		var size *api.ImageSize
+		if image_file != "" {
+			data, size, err = readRichmenuImage(image_file)
+			if err != nil {
+				return err
+			}
+		}
+		if err := api.ValidateRichmenu(menu, size); err != nil {
+			return err
+		}
+		delete(menu, "richmenuId")
+
+		bot_id, err := getTargetBotID(cmd, nil)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		created, err := client.CreateRichmenu(cmd.Context(), bot_id, menu)
+		if isDryRun(err) && image_file != "" {
+			// The image is set by the ID of the created menu, so its requests cannot be shown
+			fmt.Fprintf(os.Stderr, "dry run: %s will be uploaded after the rich menu is created\n", image_file)
+		}
+		if err != nil {
+			return err
+		}
+		if image_file != "" {
+			if created["richmenuId"] == nil {
+				return errors.New("rich menu is created, but richmenuId is not in the response. The image is not uploaded")
+			}
+			richmenu_id := formatID(created["richmenuId"])
+			if err := uploadRichmenuImage(cmd, client, bot_id, richmenu_id, image_file, data); err != nil {
+				return fmt.Errorf("rich menu %s is created, but failed to upload the image: %w", richmenu_id, err)
+			}
+		}
+		return printOutput(cmd, created)
+	},
+}
+
+var botRichmenuUploadImageCmd = &cobra.Command{
+	Use:   "upload-image RICHMENU_ID",
+	Short: "Upload the image of a rich menu.",
+	Long: `Upload the image of a rich menu.
+
+The image must be PNG or JPEG, and its size must match the menu size.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		image_file, _ := cmd.Flags().GetString("image")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		data, size, err := readRichmenuImage(image_file)
+		if err != nil {
+			return err
+		}
+		bot_id, err := getTargetBotID(cmd, nil)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		if !dryRun {
+			// Check the image against the registered menu
+			menu, err := client.GetRichmenu(cmd.Context(), bot_id, args[0])
+			if err != nil {
+				return err
+			}
+			if err := api.ValidateRichmenu(menu, size); err != nil {
+				return err
+			}
+		}
+		return uploadRichmenuImage(cmd, client, bot_id, args[0], image_file, data)
+	},
+}
+
+var botRichmenuListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List rich menus of the bot.",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, nil)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return printList(cmd, client, api.RichmenuPath(bot_id))
+	},
+}
+
+var botRichmenuDeleteCmd = &cobra.Command{
+	Use:   "delete RICHMENU_ID",
+	Short: "Delete a rich menu.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, nil)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return client.DeleteRichmenu(cmd.Context(), bot_id, args[0])
+	},
+}
+
+var botRichmenuSetDefaultCmd = &cobra.Command{
+	Use:   "set-default RICHMENU_ID",
+	Short: "Set the default rich menu of the bot.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, nil)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return client.SetDefaultRichmenu(cmd.Context(), bot_id, args[0])
+	},
+}
+
+var botRichmenuAssignUserCmd = &cobra.Command{
+	Use:   "assign-user RICHMENU_ID USER_ID",
+	Short: "Set the rich menu of a user.",
+	Args:  cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, nil)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return client.SetUserRichmenu(cmd.Context(), bot_id, args[0], args[1])
+	},
+}
+
+var botRichmenuUnassignUserCmd = &cobra.Command{
+	Use:   "unassign-user USER_ID",
+	Short: "Remove the rich menu of a user. The default rich menu is shown to the user.",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+
+		cmd.SilenceUsage = true
+		cmd.SilenceErrors = true
+
+		bot_id, err := getTargetBotID(cmd, nil)
+		if err != nil {
+			return err
+		}
+		client, err := newAPIClient(profile, BOT_SCOPES)
+		if err != nil {
+			return err
+		}
+		return client.DeleteUserRichmenu(cmd.Context(), bot_id, args[0])
+	},
+}
+
+func init() {
+	botCmd.AddCommand(botRichmenuCmd)
+	botRichmenuCmd.AddCommand(botRichmenuCreateCmd)
+	botRichmenuCmd.AddCommand(botRichmenuUploadImageCmd)
+	botRichmenuCmd.AddCommand(botRichmenuListCmd)
+	botRichmenuCmd.AddCommand(botRichmenuDeleteCmd)
+	botRichmenuCmd.AddCommand(botRichmenuSetDefaultCmd)
+	botRichmenuCmd.AddCommand(botRichmenuAssignUserCmd)
+	botRichmenuCmd.AddCommand(botRichmenuUnassignUserCmd)
+
+	botRichmenuCreateCmd.Flags().StringP("input", "", "", "JSON or YAML file of the rich menu. Use - for stdin")
+	botRichmenuCreateCmd.MarkFlagRequired("input")
+	botRichmenuCreateCmd.Flags().StringP("image", "", "", "PNG or JPEG image file to upload as the menu image")
+
+	botRichmenuUploadImageCmd.Flags().StringP("image", "", "", "PNG or JPEG image file")
+	botRichmenuUploadImageCmd.MarkFlagRequired("image")
+
+	addPaginationFlags(botRichmenuListCmd)
+}
@@ -1,17 +1,114 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"os"
 
 	"github.com/spf13/cobra"
 
 	"github.com/mmclsntr/lineworks-cli/auth"
+	"github.com/mmclsntr/lineworks-cli/httpclient"
 )
 
 var rootCmd = &cobra.Command{
 	Use:   "lineworks",
 	Short: "Command line tool for LINE WORKS API",
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("output")
+		if err := validateOutputFormat(format); err != nil {
+			return err
+		}
+		return setupHTTPClient(cmd)
+	},
+}
+
+// Dry-run mode. Requests are printed instead of being sent.
+var dryRun bool
+
+// Replay mode. Responses are served from a cassette.
+var replaying bool
+
+// HAR recorder of --har. The file is written when the command finishes.
+var harRecorder *httpclient.HARRecorder
+
+// Cassette recorder of --record. The file is written when the command finishes.
+var cassetteRecorder *httpclient.CassetteRecorder
+
+// Write files of HTTP recorders
+func closeHTTPRecorders() {
+	if harRecorder != nil {
+		if err := harRecorder.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write HAR file: %s\n", err)
+		}
+		harRecorder = nil
+	}
+	if cassetteRecorder != nil {
+		if err := cassetteRecorder.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed to write cassette file: %s\n", err)
+		}
+		cassetteRecorder = nil
+	}
+}
+
+// Check the error is caused by dry-run mode
+func isDryRun(err error) bool {
+	return errors.Is(err, httpclient.ErrDryRun)
+}
+
+// Set up the shared HTTP client from global and profile settings
+func setupHTTPClient(cmd *cobra.Command) error {
+	profile := ""
+	if f := cmd.Flags().Lookup("profile"); f != nil {
+		profile = f.Value.String()
+	}
+
+	conf, err := auth.LoadHTTPConfig(profile)
+	if err != nil {
+		return err
+	}
+	wrappers := []func(http.RoundTripper) http.RoundTripper{}
+	dryRun, _ = cmd.Flags().GetBool("dry-run")
+	if dryRun {
+		dryRunFormat, _ := cmd.Flags().GetString("dry-run-format")
+		if dryRunFormat != "text" && dryRunFormat != "curl" {
+			return fmt.Errorf("unknown dry-run format '%s'. Must be text or curl", dryRunFormat)
+		}
+		wrappers = append(wrappers, httpclient.DryRun(os.Stdout, dryRunFormat == "curl", ENV_ACCESS_TOKEN))
+	}
+	recordFile, _ := cmd.Flags().GetString("record")
+	replayFile, _ := cmd.Flags().GetString("replay")
+	replaying = replayFile != ""
+	if recordFile != "" && replaying {
+		return fmt.Errorf("--record and --replay can not be used together")
+	} else if replaying && dryRun {
+		return fmt.Errorf("--replay and --dry-run can not be used together")
+	}
+	if recordFile != "" {
+		cassetteRecorder = httpclient.NewCassetteRecorder(recordFile)
+		wrappers = append(wrappers, cassetteRecorder.Wrap)
+	} else if replaying {
+		replay, err := httpclient.NewReplayTransport(replayFile)
+		if err != nil {
+			return err
+		}
+		wrappers = append(wrappers, replay.Wrap)
+	}
+	if debug, _ := cmd.Flags().GetBool("debug"); debug {
+		wrappers = append(wrappers, httpclient.Debug(os.Stderr))
+	}
+	if harFile, _ := cmd.Flags().GetString("har"); harFile != "" {
+		harRecorder = httpclient.NewHARRecorder(harFile)
+		wrappers = append(wrappers, harRecorder.Wrap)
+	}
+
+	client, err := httpclient.New(conf, wrappers...)
+	if err != nil {
+		return err
+	}
+	auth.SetHTTPClient(client)
+	return nil
 }
 
 var listProfilesCmd = &cobra.Command{
@@ -19,6 +116,9 @@ var listProfilesCmd = &cobra.Command{
 	Short: "List profiles",
 	RunE: func(cmd *cobra.Command, args []string) error {
 		profiles := auth.ListConfigProfiles()
+		if outputRequested(cmd) {
+			return printOutput(cmd, profiles)
+		}
 		for _, p := range profiles {
 			fmt.Println(p)
 		}
@@ -29,12 +129,26 @@ var listProfilesCmd = &cobra.Command{
 func Execute() {
 	rootCmd.CompletionOptions.DisableDefaultCmd = true
 
-	if err := rootCmd.Execute(); err != nil {
-		fmt.Println(err)
+	err := rootCmd.Execute()
+	closeHTTPRecorders()
+	if isDryRun(err) {
+		return
+	} else if err != nil {
+		// Errors are kept out of stdout, which may be piped to other tools
+		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
 }
 
 func init() {
+	rootCmd.PersistentFlags().StringP("output", "o", "json", "Output format. json, yaml, table, csv or tsv")
+	rootCmd.PersistentFlags().StringP("query", "", "", "JMESPath query applied to output (ex. 'users[].email')")
+	rootCmd.PersistentFlags().BoolP("debug", "", false, "Log HTTP requests and responses to stderr. Credentials are redacted")
+	rootCmd.PersistentFlags().BoolP("dry-run", "", false, "Print requests instead of sending them")
+	rootCmd.PersistentFlags().StringP("dry-run-format", "", "text", "Format of --dry-run. text or curl (the access token is substituted by $"+ENV_ACCESS_TOKEN+")")
+	rootCmd.PersistentFlags().StringP("har", "", "", "Write HTTP requests and responses to the HAR file. Credentials are redacted")
+	rootCmd.PersistentFlags().StringP("record", "", "", "Record HTTP requests and responses to the cassette file. Credentials are redacted")
+	rootCmd.PersistentFlags().StringP("replay", "", "", "Serve HTTP responses from the cassette file instead of sending requests")
+
 	rootCmd.AddCommand(listProfilesCmd)
 }
@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/mmclsntr/lineworks-cli/auth"
+)
+
+const DEFAULT_SERVE_PORT = "9877"
+
+// Environment variable of the session secret of serve
+const ENV_SERVE_SECRET = "LINEWORKS_SERVE_SECRET"
+
+// Token vending server
+type tokenServer struct {
+	profile     string
+	secret      string
+	renewBefore time.Duration
+
+	mu sync.Mutex
+	// Token sources per scope set requested so far. They are renewed in background.
+	sources map[string]*auth.TokenSource
+}
+
+func (srv *tokenServer) getTokenSource(scopes string) (*auth.TokenSource, error) {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	key := auth.NormalizeScopes(scopes)
+	if ts, ok := srv.sources[key]; ok {
+		return ts, nil
+	}
+	ts, err := getTokenSource(srv.profile, key)
+	if err != nil {
+		return nil, err
+	}
+	ts.RenewBefore = srv.renewBefore
+	srv.sources[key] = ts
+	return ts, nil
+}
+
+// Renew tokens which expire soon
+func (srv *tokenServer) renewTokens() {
+	srv.mu.Lock()
+	sources := map[string]*auth.TokenSource{}
+	for k, ts := range srv.sources {
+		sources[k] = ts
+	}
+	srv.mu.Unlock()
+
+	for scopes, ts := range sources {
+		if _, err := ts.AccessToken(); err != nil {
+			log.Printf("failed to renew token (scopes '%s'): %s", scopes, err)
+		}
+	}
+}
+
+func (srv *tokenServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	expected := "Bearer " + srv.secret
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(expected)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	ts, err := srv.getTokenSource(r.URL.Query().Get("scopes"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	token, err := ts.AccessToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out, err := formatToken(token, "json")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	fmt.Fprint(w, out)
+}
+
+func generateSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Start token vending server
+func serveTokens(srv *tokenServer, listener net.Listener) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	mux := http.NewServeMux()
+	mux.Handle("/token", srv)
+	httpSrv := &http.Server{Handler: mux}
+
+	// Background renewal
+	go func() {
+		ticker := time.NewTicker(30 * time.Second)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				srv.renewTokens()
+			}
+		}
+	}()
+
+	go func() {
+		<-ctx.Done()
+		httpSrv.Shutdown(context.Background())
+	}()
+
+	if err := httpSrv.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+var authServeCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Serve access tokens on a local HTTP endpoint.",
+	Long: `Serve access tokens on a local HTTP endpoint. Tokens are renewed in background before expiry.
+
+The session secret is read from $` + ENV_SERVE_SECRET + `, or generated and shown at startup.
+--secret is also accepted, but it is visible to other users in the process list.
+
+Request with the session secret.
+  curl -H "Authorization: Bearer <secret>" "http://127.0.0.1:9877/token?scopes=bot"`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		profile, _ := cmd.Flags().GetString("profile")
+		addr, _ := cmd.Flags().GetString("addr")
+		port, _ := cmd.Flags().GetString("port")
+		socket, _ := cmd.Flags().GetString("socket")
+		secret, _ := cmd.Flags().GetString("secret")
+		renew_before, _ := cmd.Flags().GetDuration("renew-before")
+
+		// Check the profile has a token
+		if _, err := getToken(profile); err != nil {
+			fmt.Printf("%s", err)
+			return nil
+		}
+
+		show_secret := false
+		if s := os.Getenv(ENV_SERVE_SECRET); s != "" {
+			secret = s
+		} else if secret != "" {
+			fmt.Fprintf(os.Stderr, "Warning: --secret is visible in the process list and shell history. Set %s instead.\n", ENV_SERVE_SECRET)
+		} else {
+			show_secret = true
+			s, err := generateSecret()
+			if err != nil {
+				fmt.Printf("%s", err)
+				return nil
+			}
+			secret = s
+		}
+
+		var listener net.Listener
+		var err error
+		if socket != "" {
+			os.Remove(socket)
+			listener, err = net.Listen("unix", socket)
+			if err == nil {
+				defer os.Remove(socket)
+				err = os.Chmod(socket, 0600)
+			}
+		} else {
+			listener, err = net.Listen("tcp", fmt.Sprintf("%s:%s", addr, port))
+		}
+		if err != nil {
+			fmt.Printf("%s", err)
+			return nil
+		}
+
+		fmt.Printf("Listening on %s\n", listener.Addr())
+		if show_secret {
+			fmt.Printf("Secret: %s\n", secret)
+		}
+
+		srv := &tokenServer{
+			profile:     profile,
+			secret:      secret,
+			renewBefore: renew_before,
+			sources:     map[string]*auth.TokenSource{},
+		}
+		if err := serveTokens(srv, listener); err != nil {
+			fmt.Printf("%s", err)
+		}
+		return nil
+	},
+}
+
+func init() {
+	authCmd.AddCommand(authServeCmd)
+
+	authServeCmd.Flags().StringP("addr", "", DEFAULT_ADDR, "Listening address")
+	authServeCmd.Flags().StringP("port", "", DEFAULT_SERVE_PORT, "Listening port")
+	authServeCmd.Flags().StringP("socket", "", "", "Listen on the unix socket path instead of TCP")
+	authServeCmd.Flags().StringP("secret", "", "", "Bearer secret for clients, used if $"+ENV_SERVE_SECRET+" is not set. Prefer the environment variable")
+	authServeCmd.Flags().DurationP("renew-before", "", 5*time.Minute, "Renew tokens this long before expiry")
+}
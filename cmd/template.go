@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+	"time"
+	"unicode/utf8"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Extensions of template files, removed before checking the content format
+var templateExtensions = []string{".tmpl", ".tpl", ".gotmpl"}
+
+// Helper functions available in message templates
+var templateFuncs = template.FuncMap{
+	// Dates
+	"now": time.Now,
+	"date": func(layout string, v interface{}) (string, error) {
+		t, err := toTime(v)
+		if err != nil {
+			return "", err
+		}
+		return t.Format(layout), nil
+	},
+	"parseTime": func(layout string, s string) (time.Time, error) {
+		return time.Parse(layout, s)
+	},
+	"addDuration": func(d string, v interface{}) (time.Time, error) {
+		t, err := toTime(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		duration, err := time.ParseDuration(d)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.Add(duration), nil
+	},
+	"tz": func(name string, v interface{}) (time.Time, error) {
+		t, err := toTime(v)
+		if err != nil {
+			return time.Time{}, err
+		}
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		return t.In(loc), nil
+	},
+	// Escaping. json returns a quoted JSON string, which is also valid in YAML.
+	"json": func(v interface{}) (string, error) {
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false)
+		if err := enc.Encode(v); err != nil {
+			return "", err
+		}
+		return strings.TrimSuffix(buf.String(), "\n"), nil
+	},
+	"quote": strconv.Quote,
+	// Strings
+	"upper":   strings.ToUpper,
+	"lower":   strings.ToLower,
+	"trim":    strings.TrimSpace,
+	"replace": func(old string, new string, s string) string { return strings.ReplaceAll(s, old, new) },
+	"join":    func(sep string, a []interface{}) string { return joinValues(sep, a) },
+	"truncate": func(n int, s string) string {
+		if utf8.RuneCountInString(s) <= n {
+			return s
+		}
+		return string([]rune(s)[:n])
+	},
+	"default": func(def interface{}, v interface{}) interface{} {
+		if v == nil || v == "" {
+			return def
+		}
+		return v
+	},
+	"env": os.Getenv,
+}
+
+// Convert time.Time, RFC3339 string or unix seconds to time
+func toTime(v interface{}) (time.Time, error) {
+	switch t := v.(type) {
+	case time.Time:
+		return t, nil
+	case string:
+		return time.Parse(time.RFC3339, t)
+	case int:
+		return time.Unix(int64(t), 0), nil
+	case int64:
+		return time.Unix(t, 0), nil
+	case float64:
+		return time.Unix(int64(t), 0), nil
+	}
+	return time.Time{}, fmt.Errorf("can not convert %v to time", v)
+}
+
+func joinValues(sep string, a []interface{}) string {
+	s := make([]string, len(a))
+	for i, v := range a {
+		s[i] = fmt.Sprint(v)
+	}
+	return strings.Join(s, sep)
+}
+
+// Add flags for template variables
+func addTemplateVarFlags(c *cobra.Command) {
+	c.Flags().StringArrayP("var", "", []string{}, "Template variable in key=value format")
+	c.Flags().StringP("vars-file", "", "", "JSON or YAML file of template variables. Use - for stdin")
+}
+
+// Load template variables from --vars-file and --var. --var takes precedence.
+func loadTemplateVars(cmd *cobra.Command) (map[string]interface{}, error) {
+	vars, _ := cmd.Flags().GetStringArray("var")
+	vars_file, _ := cmd.Flags().GetString("vars-file")
+
+	data := map[string]interface{}{}
+	if vars_file != "" {
+		b, err := readInput(vars_file)
+		if err != nil {
+			return nil, err
+		}
+		// JSON is also parsed as YAML
+		if err := yaml.Unmarshal(b, &data); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", vars_file, err)
+		}
+		if data == nil {
+			data = map[string]interface{}{}
+		}
+	}
+	fields, err := parseFields(vars)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range fields {
+		data[k] = v
+	}
+	return data, nil
+}
+
+// Parse template file. Undefined variables are errors, unless the template uses default for optional ones.
+func parseTemplate(file string) (*template.Template, error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := template.New(filepath.Base(file)).Funcs(templateFuncs).Parse(string(b))
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil && usesFunc(t.Tree.Root, "default") {
+			// Missing keys are passed to default as nil
+			return tmpl.Option("missingkey=default"), nil
+		}
+	}
+	return tmpl.Option("missingkey=error"), nil
+}
+
+// Check the function is called in the template node
+func usesFunc(node parse.Node, name string) bool {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Nodes {
+			if usesFunc(c, name) {
+				return true
+			}
+		}
+	case *parse.ActionNode:
+		return usesFunc(n.Pipe, name)
+	case *parse.TemplateNode:
+		return usesFunc(n.Pipe, name)
+	case *parse.IfNode:
+		return usesFunc(n.Pipe, name) || usesFunc(n.List, name) || usesFunc(n.ElseList, name)
+	case *parse.RangeNode:
+		return usesFunc(n.Pipe, name) || usesFunc(n.List, name) || usesFunc(n.ElseList, name)
+	case *parse.WithNode:
+		return usesFunc(n.Pipe, name) || usesFunc(n.List, name) || usesFunc(n.ElseList, name)
+	case *parse.PipeNode:
+		if n == nil {
+			return false
+		}
+		for _, c := range n.Cmds {
+			if usesFunc(c, name) {
+				return true
+			}
+		}
+	case *parse.CommandNode:
+		for _, a := range n.Args {
+			if usesFunc(a, name) {
+				return true
+			}
+		}
+	case *parse.ChainNode:
+		return usesFunc(n.Node, name)
+	case *parse.IdentifierNode:
+		return n.Ident == name
+	}
+	return false
+}
+
+// Render template with data
+func renderTemplate(tmpl *template.Template, data interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Check the template renders rich message content, by the extension before the template extension.
+// msg.json.tmpl and msg.yaml.tmpl are content, and others (ex. msg.tmpl, msg.txt.tmpl) are text.
+func isContentTemplate(file string) bool {
+	name := strings.ToLower(filepath.Base(file))
+	for _, ext := range templateExtensions {
+		name = strings.TrimSuffix(name, ext)
+	}
+	switch filepath.Ext(name) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
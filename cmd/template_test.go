@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	data := map[string]interface{}{
+		"name":  "Alice",
+		"empty": "",
+		"since": "2024-01-01T09:00:00Z",
+		"tags":  []interface{}{"a", "b"},
+	}
+	cases := []struct {
+		name string
+		tmpl string
+		want string
+		err  bool
+	}{
+		{"variable", `Hi {{.name}}`, "Hi Alice", false},
+		{"undefined variable", `Hi {{.owner}}`, "", true},
+		{"default for undefined variable", `Owner: {{default "on-call" .owner}}`, "Owner: on-call", false},
+		{"default for empty value", `{{default "none" .empty}}`, "none", false},
+		{"default keeps set value", `{{default "x" .name}}`, "Alice", false},
+		{"default in a branch", `{{if .name}}{{default "x" .owner}}{{end}}`, "x", false},
+		{"default in a defined template", `{{define "t"}}{{default "x" .owner}}{{end}}{{template "t" .}}`, "x", false},
+		{"date in time zone", `{{date "2006-01-02 15:04" (tz "Asia/Tokyo" .since)}}`, "2024-01-01 18:00", false},
+		{"json escapes", `{{json "a\"b"}}`, `"a\"b"`, false},
+		{"join", `{{join ", " .tags}}`, "a, b", false},
+		{"truncate", `{{truncate 3 "あいうえお"}}`, "あいう", false},
+		{"replace", `{{replace "-" "_" "a-b"}}`, "a_b", false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "msg.tmpl")
+			if err := os.WriteFile(file, []byte(c.tmpl), 0600); err != nil {
+				t.Fatal(err)
+			}
+			tmpl, err := parseTemplate(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			b, err := renderTemplate(tmpl, data)
+			if c.err {
+				if err == nil {
+					t.Fatalf("no error, rendered %q", b)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != c.want {
+				t.Errorf("rendered %q, want %q", b, c.want)
+			}
+		})
+	}
+}
+
+func TestIsContentTemplate(t *testing.T) {
+	cases := map[string]bool{
+		"msg.json.tmpl":   true,
+		"msg.yaml.gotmpl": true,
+		"MSG.YML.TPL":     true,
+		"msg.tmpl":        false,
+		"msg.txt.tmpl":    false,
+		"dir.json/msg":    false,
+	}
+	for file, want := range cases {
+		if got := isContentTemplate(file); got != want {
+			t.Errorf("isContentTemplate(%s) = %v, want %v", file, got, want)
+		}
+	}
+}
@@ -6,8 +6,11 @@ require (
 	github.com/BurntSushi/toml v1.2.0
 	github.com/golang-jwt/jwt/v4 v4.4.2
 	github.com/google/uuid v1.3.0
+	github.com/jmespath/go-jmespath v0.4.0
 	github.com/pkg/browser v0.0.0-20210911075715-681adbf594b8
 	github.com/spf13/cobra v1.5.0
+	golang.org/x/oauth2 v0.20.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -0,0 +1,236 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Returned in replay mode when no recorded interaction matches the request
+var ErrCassetteMiss = errors.New("no matching interaction in cassette")
+
+const CASSETTE_VERSION = 1
+
+// Cassette holds recorded HTTP interactions. Credentials are redacted.
+type Cassette struct {
+	Version      int           `json:"version"`
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Recorded request and response
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+type RecordedResponse struct {
+	StatusCode int         `json:"statusCode"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body,omitempty"`
+}
+
+// Read cassette file
+func ReadCassette(path string) (*Cassette, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Cassette{}
+	if err := json.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid cassette %s: %w", path, err)
+	}
+	if c.Version != CASSETTE_VERSION {
+		return nil, fmt.Errorf("unsupported cassette version %d", c.Version)
+	}
+	return c, nil
+}
+
+// Write cassette file
+func (c *Cassette) Write(path string) error {
+	// Keep bodies readable for review
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(c); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+// CassetteRecorder records requests and responses to a cassette file.
+// Interactions are kept in memory, and the file is written by Close.
+type CassetteRecorder struct {
+	Path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// Create CassetteRecorder. An existing file is overwritten.
+func NewCassetteRecorder(path string) *CassetteRecorder {
+	return &CassetteRecorder{
+		Path:     path,
+		cassette: Cassette{Version: CASSETTE_VERSION, Interactions: []Interaction{}},
+	}
+}
+
+// Wrapper for New
+func (r *CassetteRecorder) Wrap(base http.RoundTripper) http.RoundTripper {
+	return &recordTransport{Base: base, Recorder: r}
+}
+
+type recordTransport struct {
+	Base     http.RoundTripper
+	Recorder *CassetteRecorder
+}
+
+func (t *recordTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, err := recordedRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	res, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	resBody, _, err := responseBodyLog(res)
+	if err != nil {
+		return nil, err
+	}
+
+	// The body length may change by redaction
+	header := RedactHeader(res.Header)
+	header.Del("Content-Length")
+
+	interaction := Interaction{
+		Request: recordRequest(req, reqBody),
+		Response: RecordedResponse{
+			StatusCode: res.StatusCode,
+			Headers:    header,
+			Body:       string(resBody),
+		},
+	}
+	t.Recorder.add(interaction)
+	return res, nil
+}
+
+func (r *CassetteRecorder) add(interaction Interaction) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+}
+
+// Write the recorded interactions to the file
+func (r *CassetteRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Write(r.Path)
+}
+
+// Request body in the recorded form with credentials redacted.
+// Bodies which are not text (ex. multipart uploads) are recorded as a placeholder of the size and media type.
+// Parameters such as the multipart boundary are left out, so that the placeholder matches in replay.
+func recordedRequestBody(req *http.Request) ([]byte, error) {
+	contentType := req.Header.Get("Content-Type")
+	if req.Body != nil && req.Body != http.NoBody && !isTextBody(contentType) {
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		return bodyPlaceholder(req.ContentLength, mediaType), nil
+	}
+	body, _, err := requestBodyLog(req)
+	return body, err
+}
+
+// Request in the recorded form, which is also used to match requests in replay mode.
+// body is the recorded form of the body.
+func recordRequest(req *http.Request, body []byte) RecordedRequest {
+	return RecordedRequest{
+		Method:  req.Method,
+		URL:     RedactURL(req.URL),
+		Headers: RedactHeader(req.Header),
+		Body:    string(body),
+	}
+}
+
+// ReplayTransport serves recorded responses instead of sending requests.
+// A request matches an unused interaction with the same method and URL, preferring one with the same body.
+// Interactions are used in the recorded order, so that repeated requests get the same sequence of responses.
+type ReplayTransport struct {
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// Create ReplayTransport from the cassette file
+func NewReplayTransport(path string) (*ReplayTransport, error) {
+	c, err := ReadCassette(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayTransport{cassette: c, used: make([]bool, len(c.Interactions))}, nil
+}
+
+// Wrapper for New. The base transport is never used.
+func (t *ReplayTransport) Wrap(base http.RoundTripper) http.RoundTripper {
+	return t
+}
+
+func (t *ReplayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := recordedRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+	closeRequestBody(req)
+	recorded := recordRequest(req, body)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	found := -1
+	for i, interaction := range t.cassette.Interactions {
+		if t.used[i] || interaction.Request.Method != recorded.Method || interaction.Request.URL != recorded.URL {
+			continue
+		}
+		if interaction.Request.Body == recorded.Body {
+			found = i
+			break
+		}
+		if found < 0 {
+			found = i
+		}
+	}
+	if found < 0 {
+		return nil, fmt.Errorf("%w: %s %s", ErrCassetteMiss, recorded.Method, recorded.URL)
+	}
+	t.used[found] = true
+
+	recordedRes := t.cassette.Interactions[found].Response
+	header := recordedRes.Headers.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", recordedRes.StatusCode, http.StatusText(recordedRes.StatusCode)),
+		StatusCode:    recordedRes.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader([]byte(recordedRes.Body))),
+		ContentLength: int64(len(recordedRes.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,95 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+// Multipart request with a new random boundary each time
+func newUploadRequest(t *testing.T, url string) *http.Request {
+	t.Helper()
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", "a.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write([]byte("\x89PNG binary"))
+	w.Close()
+	req, err := http.NewRequest(http.MethodPut, url, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", w.FormDataContentType())
+	return req
+}
+
+func TestCassetteRecordAndReplay(t *testing.T) {
+	var uploaded []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uploaded, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"access_token":"secret-token","fileId":"f1"}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder := NewCassetteRecorder(path)
+	client := &http.Client{Transport: recorder.Wrap(http.DefaultTransport)}
+	res, err := client.Do(newUploadRequest(t, server.URL+"/upload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if !bytes.Contains(uploaded, []byte("\x89PNG binary")) {
+		t.Errorf("server got %q, want the whole upload", uploaded)
+	}
+	if string(body) != `{"access_token":"secret-token","fileId":"f1"}` {
+		t.Errorf("client got %s, want the response unchanged", body)
+	}
+
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("cassette is written before Close: %v", err)
+	}
+	if err := recorder.Close(); err != nil {
+		t.Fatal(err)
+	}
+	cassette, err := ReadCassette(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cassette.Interactions) != 1 {
+		t.Fatalf("got %d interactions, want 1", len(cassette.Interactions))
+	}
+	interaction := cassette.Interactions[0]
+	if want := "<" + strconv.Itoa(len(uploaded)) + " bytes, multipart/form-data>"; interaction.Request.Body != want {
+		t.Errorf("recorded request body = %q, want %q", interaction.Request.Body, want)
+	}
+	if want := `{"access_token":"***","fileId":"f1"}`; interaction.Response.Body != want {
+		t.Errorf("recorded response body = %q, want %q", interaction.Response.Body, want)
+	}
+
+	// The boundary differs in replay, but the request still matches the placeholder
+	replay, err := NewReplayTransport(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err = (&http.Client{Transport: replay}).Do(newUploadRequest(t, server.URL+"/upload"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, _ = io.ReadAll(res.Body)
+	res.Body.Close()
+	if string(body) != `{"access_token":"***","fileId":"f1"}` {
+		t.Errorf("replayed body = %s", body)
+	}
+}
@@ -0,0 +1,233 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+)
+
+// HTTP client settings
+type Config struct {
+	// Request timeout (ex. 30s)
+	Timeout string `toml:"timeout,omitempty" json:"timeout,omitempty"`
+	// HTTP(S) proxy URL. Proxy environment variables are used if not set.
+	Proxy string `toml:"proxy,omitempty" json:"proxy,omitempty"`
+	// PEM file of CA certificates added to the system ones
+	CABundle string `toml:"ca_bundle,omitempty" json:"ca_bundle,omitempty"`
+	// Minimum TLS version (1.0, 1.1, 1.2 or 1.3)
+	TLSMinVersion string `toml:"tls_min_version,omitempty" json:"tls_min_version,omitempty"`
+	// Max retry count on 429, 5xx and connection errors
+	MaxRetries *int `toml:"max_retries,omitempty" json:"max_retries,omitempty"`
+	// Initial wait of exponential backoff (ex. 500ms)
+	RetryWait string `toml:"retry_wait,omitempty" json:"retry_wait,omitempty"`
+	// Client-side rate limits. Key is an endpoint pattern "[METHOD ]PATH" where "*" matches a path segment,
+	// value is requests per second.
+	RateLimits map[string]float64 `toml:"rate_limits,omitempty" json:"rate_limits,omitempty"`
+	// Max concurrent requests. 0 means no limit.
+	MaxConcurrency int `toml:"max_concurrency,omitempty" json:"max_concurrency,omitempty"`
+}
+
+const DEFAULT_TIMEOUT = 30 * time.Second
+const DEFAULT_MAX_RETRIES = 2
+const DEFAULT_RETRY_WAIT = 500 * time.Millisecond
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Merge settings. Set values of the other config take precedence.
+func (conf Config) Merge(other Config) Config {
+	if other.Timeout != "" {
+		conf.Timeout = other.Timeout
+	}
+	if other.Proxy != "" {
+		conf.Proxy = other.Proxy
+	}
+	if other.CABundle != "" {
+		conf.CABundle = other.CABundle
+	}
+	if other.TLSMinVersion != "" {
+		conf.TLSMinVersion = other.TLSMinVersion
+	}
+	if other.MaxRetries != nil {
+		conf.MaxRetries = other.MaxRetries
+	}
+	if other.RetryWait != "" {
+		conf.RetryWait = other.RetryWait
+	}
+	if len(other.RateLimits) > 0 {
+		limits := map[string]float64{}
+		for k, v := range conf.RateLimits {
+			limits[k] = v
+		}
+		for k, v := range other.RateLimits {
+			limits[k] = v
+		}
+		conf.RateLimits = limits
+	}
+	if other.MaxConcurrency != 0 {
+		conf.MaxConcurrency = other.MaxConcurrency
+	}
+	return conf
+}
+
+// Validate settings
+func (conf Config) Validate() error {
+	_, err := conf.transport()
+	if err != nil {
+		return err
+	}
+	_, err = conf.retryTransport(http.DefaultTransport)
+	if err != nil {
+		return err
+	}
+	if conf.MaxConcurrency < 0 {
+		return errors.New("max concurrency must not be negative")
+	}
+	for k, v := range conf.RateLimits {
+		if v <= 0 {
+			return fmt.Errorf("rate limit of '%s' must be positive", k)
+		}
+	}
+	_, err = parseDuration(conf.Timeout, DEFAULT_TIMEOUT)
+	return err
+}
+
+// Create http.Client from the settings.
+// Wrappers wrap the base transport in order, inside of rate limit and retry. They see each actual attempt.
+// The timeout applies to each attempt, so that waits for retries and rate limits are not counted.
+func New(conf Config, wrappers ...func(http.RoundTripper) http.RoundTripper) (*http.Client, error) {
+	timeout, err := parseDuration(conf.Timeout, DEFAULT_TIMEOUT)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	base, err := conf.transport()
+	if err != nil {
+		return nil, err
+	}
+	var inner http.RoundTripper = &timeoutTransport{Base: base, Timeout: timeout}
+	for _, wrap := range wrappers {
+		inner = wrap(inner)
+	}
+	limited := NewRateLimitTransport(inner, conf.RateLimits, conf.MaxConcurrency)
+	rt, err := conf.retryTransport(limited)
+	if err != nil {
+		return nil, err
+	}
+
+	return &http.Client{Transport: rt}, nil
+}
+
+// Cancels a request attempt after the timeout. The response body can be read until then.
+type timeoutTransport struct {
+	Base    http.RoundTripper
+	Timeout time.Duration
+}
+
+func (t *timeoutTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.Timeout <= 0 {
+		return t.Base.RoundTrip(req)
+	}
+	ctx, cancel := context.WithTimeout(req.Context(), t.Timeout)
+	res, err := t.Base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if ctx.Err() == context.DeadlineExceeded && req.Context().Err() == nil {
+			return nil, fmt.Errorf("request timed out after %s: %w", t.Timeout, err)
+		}
+		return nil, err
+	}
+	res.Body = &cancelOnClose{ReadCloser: res.Body, cancel: cancel}
+	return res, nil
+}
+
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+func (conf Config) transport() (*http.Transport, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+
+	// Proxy
+	if conf.Proxy != "" {
+		proxyURL, err := url.Parse(conf.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy: %w", err)
+		}
+		tr.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{}
+
+	// CA bundle
+	if conf.CABundle != "" {
+		pem, err := os.ReadFile(conf.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificate found in ca bundle '%s'", conf.CABundle)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	// TLS min version
+	if conf.TLSMinVersion != "" {
+		v, ok := tlsVersions[conf.TLSMinVersion]
+		if !ok {
+			return nil, errors.New("invalid tls min version. Must be one of 1.0, 1.1, 1.2, 1.3")
+		}
+		tlsConfig.MinVersion = v
+	}
+
+	tr.TLSClientConfig = tlsConfig
+	return tr, nil
+}
+
+func (conf Config) retryTransport(base http.RoundTripper) (*RetryTransport, error) {
+	wait, err := parseDuration(conf.RetryWait, DEFAULT_RETRY_WAIT)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry wait: %w", err)
+	}
+	maxRetries := DEFAULT_MAX_RETRIES
+	if conf.MaxRetries != nil {
+		maxRetries = *conf.MaxRetries
+	}
+	if maxRetries < 0 {
+		return nil, errors.New("max retries must not be negative")
+	}
+
+	return &RetryTransport{
+		Base:       base,
+		MaxRetries: maxRetries,
+		Wait:       wait,
+	}, nil
+}
+
+func parseDuration(s string, defaultValue time.Duration) (time.Duration, error) {
+	if s == "" {
+		return defaultValue, nil
+	}
+	return time.ParseDuration(s)
+}
@@ -0,0 +1,171 @@
+package httpclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DebugTransport logs requests and responses with credentials redacted
+type DebugTransport struct {
+	Base http.RoundTripper
+	Out  io.Writer
+
+	mu sync.Mutex
+}
+
+// Wrapper of DebugTransport for New
+func Debug(out io.Writer) func(http.RoundTripper) http.RoundTripper {
+	return func(base http.RoundTripper) http.RoundTripper {
+		return &DebugTransport{Base: base, Out: out}
+	}
+}
+
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, _, err := requestBodyLog(req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := t.Base.RoundTrip(req)
+	elapsed := time.Since(start)
+
+	var resBody []byte
+	if err == nil {
+		resBody, _, err = responseBodyLog(res)
+	}
+
+	// Log the exchange at once, so that concurrent requests are not interleaved
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "> %s %s\n", req.Method, RedactURL(req.URL))
+	writeHeader(&buf, "> ", RedactHeader(req.Header))
+	if len(reqBody) > 0 {
+		fmt.Fprintf(&buf, ">\n> %s\n", reqBody)
+	}
+	if err != nil {
+		fmt.Fprintf(&buf, "< error: %s (%s)\n\n", err, elapsed.Round(time.Millisecond))
+	} else {
+		fmt.Fprintf(&buf, "< %s %s (%s)\n", res.Proto, res.Status, elapsed.Round(time.Millisecond))
+		writeHeader(&buf, "< ", RedactHeader(res.Header))
+		if len(resBody) > 0 {
+			fmt.Fprintf(&buf, "<\n< %s\n", resBody)
+		}
+		fmt.Fprintln(&buf)
+	}
+
+	t.mu.Lock()
+	t.Out.Write(buf.Bytes())
+	t.mu.Unlock()
+
+	return res, err
+}
+
+func writeHeader(w io.Writer, prefix string, header http.Header) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(w, "%s%s: %s\n", prefix, k, v)
+		}
+	}
+}
+
+// Read request body and restore it
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+		return io.ReadAll(body)
+	}
+
+	b, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// Read response body and restore it
+func readResponseBody(res *http.Response) ([]byte, error) {
+	b, err := io.ReadAll(res.Body)
+	res.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	res.Body = io.NopCloser(bytes.NewReader(b))
+	return b, nil
+}
+
+// Check the body can be logged as text. Multipart and binary bodies are not.
+func isTextBody(contentType string) bool {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case contentType == "",
+		strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/json",
+		mediaType == "application/x-www-form-urlencoded",
+		mediaType == "application/xml",
+		mediaType == "application/javascript",
+		strings.HasSuffix(mediaType, "+json"),
+		strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	return false
+}
+
+// Placeholder of a body which is not logged. size is -1 if unknown.
+func bodyPlaceholder(size int64, contentType string) []byte {
+	if size < 0 {
+		return []byte(fmt.Sprintf("<unknown bytes, %s>", contentType))
+	}
+	return []byte(fmt.Sprintf("<%d bytes, %s>", size, contentType))
+}
+
+// Request body for logs with credentials redacted, and its size.
+// Bodies which are not text are replaced with a placeholder without reading.
+func requestBodyLog(req *http.Request) ([]byte, int64, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, 0, nil
+	}
+	contentType := req.Header.Get("Content-Type")
+	if !isTextBody(contentType) {
+		return bodyPlaceholder(req.ContentLength, contentType), req.ContentLength, nil
+	}
+	b, err := readRequestBody(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	return RedactBody(contentType, b), int64(len(b)), nil
+}
+
+// Response body for logs with credentials redacted, and its size.
+// Bodies which are not text are replaced with a placeholder without reading.
+func responseBodyLog(res *http.Response) ([]byte, int64, error) {
+	contentType := res.Header.Get("Content-Type")
+	if !isTextBody(contentType) {
+		return bodyPlaceholder(res.ContentLength, contentType), res.ContentLength, nil
+	}
+	b, err := readResponseBody(res)
+	if err != nil {
+		return nil, 0, err
+	}
+	return RedactBody(contentType, b), int64(len(b)), nil
+}
@@ -0,0 +1,74 @@
+package httpclient
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestIsTextBody(t *testing.T) {
+	cases := []struct {
+		contentType string
+		want        bool
+	}{
+		{"", true},
+		{"application/json", true},
+		{"application/json; charset=UTF-8", true},
+		{"application/problem+json", true},
+		{"application/x-www-form-urlencoded", true},
+		{"text/plain", true},
+		{"multipart/form-data; boundary=x", false},
+		{"image/png", false},
+		{"application/octet-stream", false},
+	}
+	for _, c := range cases {
+		if got := isTextBody(c.contentType); got != c.want {
+			t.Errorf("isTextBody(%q) = %v, want %v", c.contentType, got, c.want)
+		}
+	}
+}
+
+func TestRequestBodyLog(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{"JSON is redacted", "application/json", `{"secret":"s"}`, `{"secret":"***"}`},
+		{"multipart is replaced", "multipart/form-data; boundary=x", "--x\r\nbinary", "<11 bytes, multipart/form-data; boundary=x>"},
+		{"image is replaced", "image/png", "\x89PNG", "<4 bytes, image/png>"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, "http://example.com/", strings.NewReader(c.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", c.contentType)
+			got, size, err := requestBodyLog(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != c.want {
+				t.Errorf("body = %s, want %s", got, c.want)
+			}
+			if size != int64(len(c.body)) {
+				t.Errorf("size = %d, want %d", size, len(c.body))
+			}
+			// The request can still be sent
+			b, _ := io.ReadAll(req.Body)
+			if !bytes.Equal(b, []byte(c.body)) {
+				t.Errorf("request body = %q, want %q", b, c.body)
+			}
+		})
+	}
+}
+
+func TestBodyPlaceholderUnknownSize(t *testing.T) {
+	if got := string(bodyPlaceholder(-1, "image/png")); got != "<unknown bytes, image/png>" {
+		t.Errorf("bodyPlaceholder() = %s", got)
+	}
+}
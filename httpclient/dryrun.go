@@ -0,0 +1,80 @@
+package httpclient
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Returned instead of a response in dry-run mode
+var ErrDryRun = errors.New("dry run: request was not sent")
+
+// DryRunTransport prints requests instead of sending them.
+// Credentials are redacted, or substituted by an environment variable in curl format.
+type DryRunTransport struct {
+	Out io.Writer
+	// Print as curl command
+	Curl bool
+	// Environment variable name which substitutes the bearer token in curl format
+	TokenEnv string
+}
+
+// Wrapper of DryRunTransport for New. The base transport is never used.
+func DryRun(out io.Writer, curl bool, tokenEnv string) func(http.RoundTripper) http.RoundTripper {
+	return func(base http.RoundTripper) http.RoundTripper {
+		return &DryRunTransport{Out: out, Curl: curl, TokenEnv: tokenEnv}
+	}
+}
+
+func (t *DryRunTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _, err := requestBodyLog(req)
+	if err != nil {
+		return nil, err
+	}
+	closeRequestBody(req)
+
+	if t.Curl {
+		fmt.Fprintln(t.Out, t.curl(req, body))
+		return nil, ErrDryRun
+	}
+
+	fmt.Fprintf(t.Out, "%s %s\n", req.Method, RedactURL(req.URL))
+	writeHeader(t.Out, "", RedactHeader(req.Header))
+	if len(body) > 0 {
+		fmt.Fprintf(t.Out, "\n%s\n", body)
+	}
+	fmt.Fprintln(t.Out)
+	return nil, ErrDryRun
+}
+
+func (t *DryRunTransport) curl(req *http.Request, body []byte) string {
+	lines := []string{fmt.Sprintf("curl -X %s %s", req.Method, shellQuote(RedactURL(req.URL)))}
+
+	header := RedactHeader(req.Header)
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			if k == "Authorization" && t.TokenEnv != "" {
+				scheme, _, _ := strings.Cut(v, " ")
+				lines = append(lines, fmt.Sprintf("-H %s\"$%s\"", shellQuote(k+": "+scheme+" "), t.TokenEnv))
+				continue
+			}
+			lines = append(lines, "-H "+shellQuote(k+": "+v))
+		}
+	}
+	if len(body) > 0 {
+		lines = append(lines, "--data-raw "+shellQuote(string(body)))
+	}
+	return strings.Join(lines, " \\\n  ")
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,82 @@
+package httpclient
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDryRunTransport(t *testing.T) {
+	cases := []struct {
+		name        string
+		curl        bool
+		method      string
+		url         string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			"text GET",
+			false, http.MethodGet, "https://example.com/v1.0/users?count=10", "", "",
+			"GET https://example.com/v1.0/users?count=10\nAuthorization: Bearer ***\n\n",
+		},
+		{
+			"text POST",
+			false, http.MethodPost, "https://example.com/v1.0/bots", "application/json", `{"botName":"b","botSecret":"s"}`,
+			"POST https://example.com/v1.0/bots\nAuthorization: Bearer ***\nContent-Type: application/json\n\n{\"botName\":\"b\",\"botSecret\":\"***\"}\n\n",
+		},
+		{
+			"text token request",
+			false, http.MethodPost, "https://example.com/oauth2/v2.0/token", "application/x-www-form-urlencoded", "client_secret=s&grant_type=refresh_token&refresh_token=r",
+			"POST https://example.com/oauth2/v2.0/token\nAuthorization: Bearer ***\nContent-Type: application/x-www-form-urlencoded\n\nclient_secret=***&grant_type=refresh_token&refresh_token=***\n\n",
+		},
+		{
+			"curl GET",
+			true, http.MethodGet, "https://example.com/v1.0/users", "", "",
+			"curl -X GET 'https://example.com/v1.0/users' \\\n  -H 'Authorization: Bearer '\"$LINEWORKS_ACCESS_TOKEN\"\n",
+		},
+		{
+			"curl POST with quote",
+			true, http.MethodPost, "https://example.com/v1.0/bots/1/users/u/messages", "application/json", `{"content":{"text":"it's"}}`,
+			"curl -X POST 'https://example.com/v1.0/bots/1/users/u/messages' \\\n  -H 'Authorization: Bearer '\"$LINEWORKS_ACCESS_TOKEN\" \\\n  -H 'Content-Type: application/json' \\\n  --data-raw '{\"content\":{\"text\":\"it'\\''s\"}}'\n",
+		},
+		{
+			"curl multipart",
+			true, http.MethodPost, "https://example.com/upload", "multipart/form-data; boundary=x", "--x\r\nbinary",
+			"curl -X POST 'https://example.com/upload' \\\n  -H 'Authorization: Bearer '\"$LINEWORKS_ACCESS_TOKEN\" \\\n  -H 'Content-Type: multipart/form-data; boundary=x' \\\n  --data-raw '<11 bytes, multipart/form-data; boundary=x>'\n",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var body io.Reader
+			if c.body != "" {
+				body = strings.NewReader(c.body)
+			}
+			req, err := http.NewRequest(c.method, c.url, body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Authorization", "Bearer secret-token")
+			if c.contentType != "" {
+				req.Header.Set("Content-Type", c.contentType)
+			}
+
+			var out bytes.Buffer
+			transport := DryRun(&out, c.curl, "LINEWORKS_ACCESS_TOKEN")(http.DefaultTransport)
+			res, err := transport.RoundTrip(req)
+			if res != nil || !errors.Is(err, ErrDryRun) {
+				t.Fatalf("RoundTrip() = %v, %v, want ErrDryRun", res, err)
+			}
+			if out.String() != c.want {
+				t.Errorf("output = %q, want %q", out.String(), c.want)
+			}
+			if strings.Contains(out.String(), "secret-token") {
+				t.Error("access token is printed")
+			}
+		})
+	}
+}
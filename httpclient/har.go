@@ -0,0 +1,206 @@
+package httpclient
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// HARRecorder records requests and responses to a HAR file with credentials redacted.
+// Entries are kept in memory, and the file is written by Close.
+type HARRecorder struct {
+	Path string
+
+	mu  sync.Mutex
+	har har
+}
+
+type har struct {
+	Log harLog `json:"log"`
+}
+
+type harLog struct {
+	Version string     `json:"version"`
+	Creator harCreator `json:"creator"`
+	Entries []harEntry `json:"entries"`
+}
+
+type harCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type harEntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         harRequest  `json:"request"`
+	Response        harResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         harTimings  `json:"timings"`
+}
+
+type harRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	QueryString []harNameValue `json:"queryString"`
+	PostData    *harPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []harNameValue `json:"cookies"`
+	Headers     []harNameValue `json:"headers"`
+	Content     harContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type harNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type harPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type harContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+}
+
+type harTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// Create HARRecorder
+func NewHARRecorder(path string) *HARRecorder {
+	return &HARRecorder{
+		Path: path,
+		har: har{Log: harLog{
+			Version: "1.2",
+			Creator: harCreator{Name: "lineworks-cli", Version: "1.0"},
+			Entries: []harEntry{},
+		}},
+	}
+}
+
+// Wrapper for New
+func (r *HARRecorder) Wrap(base http.RoundTripper) http.RoundTripper {
+	return &harTransport{Base: base, Recorder: r}
+}
+
+type harTransport struct {
+	Base     http.RoundTripper
+	Recorder *HARRecorder
+}
+
+func (t *harTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody, reqSize, err := requestBodyLog(req)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	res, err := t.Base.RoundTrip(req)
+	if err != nil {
+		return res, err
+	}
+	resBody, resSize, err := responseBodyLog(res)
+	if err != nil {
+		return nil, err
+	}
+	elapsed := float64(time.Since(start)) / float64(time.Millisecond)
+
+	entry := harEntry{
+		StartedDateTime: start.Format(time.RFC3339Nano),
+		Time:            elapsed,
+		Request: harRequest{
+			Method:      req.Method,
+			URL:         RedactURL(req.URL),
+			HTTPVersion: req.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(RedactHeader(req.Header)),
+			QueryString: []harNameValue{},
+			HeadersSize: -1,
+			BodySize:    int(reqSize),
+		},
+		Response: harResponse{
+			Status:      res.StatusCode,
+			StatusText:  http.StatusText(res.StatusCode),
+			HTTPVersion: res.Proto,
+			Cookies:     []harNameValue{},
+			Headers:     harHeaders(RedactHeader(res.Header)),
+			Content: harContent{
+				Size:     int(resSize),
+				MimeType: res.Header.Get("Content-Type"),
+				Text:     string(resBody),
+			},
+			HeadersSize: -1,
+			BodySize:    int(resSize),
+		},
+		Timings: harTimings{Wait: elapsed},
+	}
+	if entry.Request.HTTPVersion == "" {
+		entry.Request.HTTPVersion = "HTTP/1.1"
+	}
+	for k, values := range req.URL.Query() {
+		for _, v := range values {
+			if isSensitiveKey(k) {
+				v = REDACTED
+			}
+			entry.Request.QueryString = append(entry.Request.QueryString, harNameValue{Name: k, Value: v})
+		}
+	}
+	if len(reqBody) > 0 {
+		entry.Request.PostData = &harPostData{
+			MimeType: req.Header.Get("Content-Type"),
+			Text:     string(reqBody),
+		}
+	}
+
+	t.Recorder.add(entry)
+	return res, nil
+}
+
+func (r *HARRecorder) add(entry harEntry) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.har.Log.Entries = append(r.har.Log.Entries, entry)
+}
+
+// Write the recorded entries to the file
+func (r *HARRecorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := json.MarshalIndent(r.har, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(r.Path, b, 0600)
+}
+
+func harHeaders(header http.Header) []harNameValue {
+	headers := []harNameValue{}
+	for k, values := range header {
+		for _, v := range values {
+			headers = append(headers, harNameValue{Name: k, Value: v})
+		}
+	}
+	return headers
+}
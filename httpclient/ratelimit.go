@@ -0,0 +1,153 @@
+package httpclient
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimitTransport applies client-side rate limits per endpoint and caps concurrent requests
+type RateLimitTransport struct {
+	Base http.RoundTripper
+
+	limiters []*endpointLimiter
+	// Semaphore for concurrency. nil means no limit.
+	sem chan struct{}
+}
+
+type endpointLimiter struct {
+	method string
+	// Empty pattern matches all requests
+	pattern string
+	bucket  *tokenBucket
+}
+
+// Create RateLimitTransport.
+// Key of limits is an endpoint pattern "[METHOD ]PATH", where "*" matches a path segment
+// (ex. "POST /v1.0/bots/*/users/*/messages"). Value is requests per second.
+func NewRateLimitTransport(base http.RoundTripper, limits map[string]float64, maxConcurrency int) *RateLimitTransport {
+	t := &RateLimitTransport{Base: base}
+	for k, rate := range limits {
+		method := ""
+		pattern := strings.TrimSpace(k)
+		if parts := strings.Fields(pattern); len(parts) == 2 {
+			method = strings.ToUpper(parts[0])
+			pattern = parts[1]
+		}
+		t.limiters = append(t.limiters, &endpointLimiter{
+			method:  method,
+			pattern: pattern,
+			bucket:  newTokenBucket(rate),
+		})
+	}
+	if maxConcurrency > 0 {
+		t.sem = make(chan struct{}, maxConcurrency)
+	}
+	return t
+}
+
+// Wrapper which limits all requests to rate per second
+func RateLimit(rate float64) func(http.RoundTripper) http.RoundTripper {
+	return func(base http.RoundTripper) http.RoundTripper {
+		return &RateLimitTransport{
+			Base:     base,
+			limiters: []*endpointLimiter{{bucket: newTokenBucket(rate)}},
+		}
+	}
+}
+
+func (t *RateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	for _, l := range t.limiters {
+		if !l.match(req) {
+			continue
+		}
+		if err := l.bucket.wait(ctx); err != nil {
+			closeRequestBody(req)
+			return nil, err
+		}
+	}
+
+	if t.sem != nil {
+		select {
+		case t.sem <- struct{}{}:
+			defer func() { <-t.sem }()
+		case <-ctx.Done():
+			closeRequestBody(req)
+			return nil, ctx.Err()
+		}
+	}
+	return t.Base.RoundTrip(req)
+}
+
+func (l *endpointLimiter) match(req *http.Request) bool {
+	if l.method != "" && l.method != req.Method {
+		return false
+	}
+	if l.pattern == "" {
+		return true
+	}
+	ok, err := path.Match(l.pattern, req.URL.Path)
+	return err == nil && ok
+}
+
+// Token bucket with burst of ceil(rate)
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64) *tokenBucket {
+	burst := math.Max(1, math.Ceil(rate))
+	return &tokenBucket{
+		rate:   rate,
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// Wait until a token is available
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b.rate <= 0 {
+		return nil
+	}
+	for {
+		d := b.reserve()
+		if d == 0 {
+			return nil
+		}
+		if err := sleep(ctx, d); err != nil {
+			return err
+		}
+	}
+}
+
+// Take a token. Returns wait duration if no token is available.
+func (b *tokenBucket) reserve() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	if b.tokens >= 1 {
+		b.tokens--
+		return 0
+	}
+	return time.Duration((1 - b.tokens) / b.rate * float64(time.Second))
+}
+
+func closeRequestBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
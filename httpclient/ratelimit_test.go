@@ -0,0 +1,63 @@
+package httpclient
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTokenBucketBurst(t *testing.T) {
+	cases := []struct {
+		rate  float64
+		burst int
+	}{
+		{0.5, 1},
+		{1, 1},
+		{2.5, 3},
+		{10, 10},
+	}
+	for _, c := range cases {
+		b := newTokenBucket(c.rate)
+		for i := 0; i < c.burst; i++ {
+			if d := b.reserve(); d != 0 {
+				t.Fatalf("rate %v: token %d waits %v within the burst", c.rate, i, d)
+			}
+		}
+		d := b.reserve()
+		if d <= 0 {
+			t.Errorf("rate %v: no wait after the burst", c.rate)
+		}
+		if max := time.Duration(float64(time.Second) / c.rate); d > max {
+			t.Errorf("rate %v: wait %v is longer than %v", c.rate, d, max)
+		}
+	}
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	b := newTokenBucket(1)
+	b.reserve()
+	// Pretend a second has passed
+	b.last = b.last.Add(-time.Second)
+	if d := b.reserve(); d != 0 {
+		t.Errorf("token is not refilled, wait %v", d)
+	}
+}
+
+func TestTokenBucketWaitCanceled(t *testing.T) {
+	b := newTokenBucket(0.1)
+	b.reserve()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := b.wait(ctx); err != context.Canceled {
+		t.Errorf("wait() = %v, want context.Canceled", err)
+	}
+}
+
+func TestTokenBucketUnlimited(t *testing.T) {
+	b := newTokenBucket(0)
+	for i := 0; i < 100; i++ {
+		if err := b.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
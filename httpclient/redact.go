@@ -0,0 +1,129 @@
+package httpclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const REDACTED = "***"
+
+// Keys of form fields, JSON fields and query parameters which hold credentials
+var sensitiveKeys = map[string]bool{
+	"access_token":  true,
+	"refresh_token": true,
+	"id_token":      true,
+	"client_secret": true,
+	"assertion":     true,
+	"private_key":   true,
+	"password":      true,
+	"secret":        true,
+	"botsecret":     true,
+}
+
+// Keys which hold credentials only in form bodies.
+// Form bodies are sent only to the OAuth token endpoint, and "code" in JSON is the error code of APIs.
+var sensitiveFormKeys = map[string]bool{
+	"code": true,
+}
+
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+func isSensitiveKey(key string) bool {
+	return sensitiveKeys[strings.ToLower(key)]
+}
+
+// Redact credentials in headers. The auth scheme (ex. Bearer) is kept.
+func RedactHeader(header http.Header) http.Header {
+	h := header.Clone()
+	for _, k := range sensitiveHeaders {
+		values := h.Values(k)
+		if len(values) == 0 {
+			continue
+		}
+		redacted := make([]string, len(values))
+		for i, v := range values {
+			if scheme, _, ok := strings.Cut(v, " "); ok && k != "Cookie" && k != "Set-Cookie" {
+				redacted[i] = scheme + " " + REDACTED
+			} else {
+				redacted[i] = REDACTED
+			}
+		}
+		h[http.CanonicalHeaderKey(k)] = redacted
+	}
+	return h
+}
+
+// Redact credentials in query parameters
+func RedactURL(u *url.URL) string {
+	if u.RawQuery == "" {
+		return u.String()
+	}
+	q := u.Query()
+	changed := false
+	for k := range q {
+		if isSensitiveKey(k) {
+			q.Set(k, REDACTED)
+			changed = true
+		}
+	}
+	if !changed {
+		return u.String()
+	}
+	redacted := *u
+	redacted.RawQuery = q.Encode()
+	return redacted.String()
+}
+
+// Redact credentials in form or JSON body. Other bodies are returned as is.
+func RedactBody(contentType string, body []byte) []byte {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(body))
+		if err != nil {
+			return body
+		}
+		for k := range values {
+			if isSensitiveKey(k) || sensitiveFormKeys[strings.ToLower(k)] {
+				values.Set(k, REDACTED)
+			}
+		}
+		// Keep the mark readable
+		return []byte(strings.ReplaceAll(values.Encode(), url.QueryEscape(REDACTED), REDACTED))
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") || (mediaType == "" && json.Valid(body)):
+		var data interface{}
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+		if err := dec.Decode(&data); err != nil {
+			return body
+		}
+		b, err := json.Marshal(redactJSON(data))
+		if err != nil {
+			return body
+		}
+		return b
+	}
+	return body
+}
+
+func redactJSON(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			if isSensitiveKey(k) {
+				t[k] = REDACTED
+			} else {
+				t[k] = redactJSON(e)
+			}
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = redactJSON(e)
+		}
+	}
+	return v
+}
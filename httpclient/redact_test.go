@@ -0,0 +1,114 @@
+package httpclient
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestRedactBody(t *testing.T) {
+	cases := []struct {
+		name        string
+		contentType string
+		body        string
+		want        string
+	}{
+		{
+			"form token request",
+			"application/x-www-form-urlencoded",
+			"client_id=id&client_secret=s&code=c&grant_type=authorization_code",
+			"client_id=id&client_secret=***&code=***&grant_type=authorization_code",
+		},
+		{
+			"form assertion",
+			"application/x-www-form-urlencoded; charset=utf-8",
+			"assertion=jwt&grant_type=g",
+			"assertion=***&grant_type=g",
+		},
+		{
+			"JSON token response",
+			"application/json",
+			`{"access_token":"a","expires_in":"86400","refresh_token":"r"}`,
+			`{"access_token":"***","expires_in":"86400","refresh_token":"***"}`,
+		},
+		{
+			"JSON error code is kept",
+			"application/json",
+			`{"code":"UNAUTHORIZED","description":"invalid token"}`,
+			`{"code":"UNAUTHORIZED","description":"invalid token"}`,
+		},
+		{
+			"nested JSON",
+			"application/json; charset=UTF-8",
+			`{"bots":[{"botId":1,"botSecret":"s"}]}`,
+			`{"bots":[{"botId":1,"botSecret":"***"}]}`,
+		},
+		{
+			"JSON without content type",
+			"",
+			`{"password":"p"}`,
+			`{"password":"***"}`,
+		},
+		{
+			"invalid JSON is kept",
+			"application/json",
+			`{"access_token":`,
+			`{"access_token":`,
+		},
+		{
+			"text is kept",
+			"text/plain",
+			"access_token=a",
+			"access_token=a",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := string(RedactBody(c.contentType, []byte(c.body))); got != c.want {
+				t.Errorf("RedactBody() = %s, want %s", got, c.want)
+			}
+		})
+	}
+}
+
+func TestRedactHeader(t *testing.T) {
+	header := http.Header{}
+	header.Set("Authorization", "Bearer token")
+	header.Set("Cookie", "session=abc")
+	header.Set("Content-Type", "application/json")
+
+	got := RedactHeader(header)
+	want := map[string]string{
+		"Authorization": "Bearer ***",
+		"Cookie":        "***",
+		"Content-Type":  "application/json",
+	}
+	for k, v := range want {
+		if got.Get(k) != v {
+			t.Errorf("%s = %s, want %s", k, got.Get(k), v)
+		}
+	}
+	if header.Get("Authorization") != "Bearer token" {
+		t.Error("original header is modified")
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	cases := []struct {
+		url  string
+		want string
+	}{
+		{"https://example.com/users", "https://example.com/users"},
+		{"https://example.com/users?count=10", "https://example.com/users?count=10"},
+		{"https://example.com/token?access_token=a&count=10", "https://example.com/token?access_token=%2A%2A%2A&count=10"},
+	}
+	for _, c := range cases {
+		u, err := url.Parse(c.url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := RedactURL(u); got != c.want {
+			t.Errorf("RedactURL(%s) = %s, want %s", c.url, got, c.want)
+		}
+	}
+}
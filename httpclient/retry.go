@@ -0,0 +1,188 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// RetryTransport retries requests with exponential backoff on 5xx responses and connection errors.
+// Non-idempotent requests (ex. POST) are retried only on 429, or when the connection failed before sending,
+// so that a request accepted by the server is not sent twice.
+// On 429 responses, Retry-After header is respected.
+type RetryTransport struct {
+	Base       http.RoundTripper
+	MaxRetries int
+	// Initial wait. It is doubled for each retry.
+	Wait time.Duration
+}
+
+func (t *RetryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		sent := &sendTrace{}
+		res, err := t.Base.RoundTrip(req.WithContext(httptrace.WithClientTrace(req.Context(), sent.trace())))
+		if attempt >= t.MaxRetries || !shouldRetry(req, res, err, sent.unsent()) {
+			return res, err
+		}
+
+		// The request body must be re-readable
+		var body io.ReadCloser
+		if req.GetBody != nil {
+			var bodyErr error
+			body, bodyErr = req.GetBody()
+			if bodyErr != nil {
+				return res, err
+			}
+		} else if req.Body != nil && req.Body != http.NoBody {
+			return res, err
+		}
+
+		wait := t.backoff(attempt)
+		if res != nil {
+			if d, ok := retryAfter(res); ok {
+				wait = d
+			}
+			io.Copy(io.Discard, res.Body)
+			res.Body.Close()
+		}
+
+		if err := sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+
+		if body != nil {
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+	}
+}
+
+// Wait with jitter
+func (t *RetryTransport) backoff(attempt int) time.Duration {
+	wait := t.Wait << uint(attempt)
+	if wait <= 0 {
+		return 0
+	}
+	return wait/2 + time.Duration(rand.Int63n(int64(wait/2)+1))
+}
+
+// Tracks whether a request may have reached the server
+type sendTrace struct {
+	mu         sync.Mutex
+	connected  bool
+	dialFailed bool
+}
+
+func (s *sendTrace) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			if info.Err != nil {
+				s.set(&s.dialFailed)
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			if err != nil {
+				s.set(&s.dialFailed)
+			}
+		},
+		GotConn: func(httptrace.GotConnInfo) {
+			s.set(&s.connected)
+		},
+	}
+}
+
+func (s *sendTrace) set(b *bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	*b = true
+}
+
+// The request failed to connect, so nothing was written
+func (s *sendTrace) unsent() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.dialFailed && !s.connected
+}
+
+// Records whether requests made with a context may have reached the server, over all attempts
+type SendTracker struct {
+	trace sendTrace
+}
+
+// Track requests made with the returned context
+func TrackSend(ctx context.Context) (context.Context, *SendTracker) {
+	t := &SendTracker{}
+	return httptrace.WithClientTrace(ctx, t.trace.trace()), t
+}
+
+// A connection was made, so the request may have been written.
+// False means every attempt failed before sending, or no request was made.
+func (t *SendTracker) MaybeSent() bool {
+	t.trace.mu.Lock()
+	defer t.trace.mu.Unlock()
+	return t.trace.connected
+}
+
+// Methods which can be sent again without side effects
+func isIdempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// unsent is true if the request failed before anything was written
+func shouldRetry(req *http.Request, res *http.Response, err error, unsent bool) bool {
+	if req.Context().Err() != nil {
+		return false
+	}
+	if err != nil {
+		// Timeout of an attempt is retried like connection errors
+		if errors.Is(err, context.Canceled) || errors.Is(err, ErrDryRun) || errors.Is(err, ErrCassetteMiss) {
+			return false
+		}
+		return unsent || isIdempotent(req.Method)
+	}
+	if res.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return res.StatusCode >= 500 && isIdempotent(req.Method)
+}
+
+// Parse Retry-After header (seconds or HTTP date)
+func retryAfter(res *http.Response) (time.Duration, bool) {
+	v := res.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+	if sec, err := strconv.Atoi(v); err == nil && sec >= 0 {
+		return time.Duration(sec) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		d := time.Until(t)
+		if d < 0 {
+			d = 0
+		}
+		return d, true
+	}
+	return 0, false
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,198 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestShouldRetry(t *testing.T) {
+	netErr := errors.New("connection reset")
+	cases := []struct {
+		name   string
+		method string
+		status int
+		err    error
+		unsent bool
+		want   bool
+	}{
+		{"GET 200", http.MethodGet, 200, nil, false, false},
+		{"GET 404", http.MethodGet, 404, nil, false, false},
+		{"GET 429", http.MethodGet, 429, nil, false, true},
+		{"GET 502", http.MethodGet, 502, nil, false, true},
+		{"DELETE 503", http.MethodDelete, 503, nil, false, true},
+		{"POST 429", http.MethodPost, 429, nil, false, true},
+		{"POST 502", http.MethodPost, 502, nil, false, false},
+		{"PATCH 500", http.MethodPatch, 500, nil, false, false},
+		{"GET network error", http.MethodGet, 0, netErr, false, true},
+		{"POST network error after sending", http.MethodPost, 0, netErr, false, false},
+		{"POST network error before sending", http.MethodPost, 0, netErr, true, true},
+		{"GET canceled", http.MethodGet, 0, context.Canceled, false, false},
+		{"GET dry run", http.MethodGet, 0, ErrDryRun, true, false},
+		{"GET cassette miss", http.MethodGet, 0, ErrCassetteMiss, true, false},
+		{"GET attempt timeout", http.MethodGet, 0, context.DeadlineExceeded, false, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			req := httptest.NewRequest(c.method, "http://example.com/", nil)
+			var res *http.Response
+			if c.err == nil {
+				res = &http.Response{StatusCode: c.status}
+			}
+			if got := shouldRetry(req, res, c.err, c.unsent); got != c.want {
+				t.Errorf("shouldRetry() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
+
+func TestShouldRetryCanceledRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/", nil).WithContext(ctx)
+	if shouldRetry(req, &http.Response{StatusCode: 503}, nil, false) {
+		t.Error("canceled request is retried")
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name   string
+		header string
+		want   time.Duration
+		ok     bool
+	}{
+		{"none", "", 0, false},
+		{"seconds", "3", 3 * time.Second, true},
+		{"zero", "0", 0, true},
+		{"negative", "-1", 0, false},
+		{"past date", "Mon, 02 Jan 2006 15:04:05 GMT", 0, true},
+		{"invalid", "soon", 0, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			res := &http.Response{Header: http.Header{}}
+			if c.header != "" {
+				res.Header.Set("Retry-After", c.header)
+			}
+			got, ok := retryAfter(res)
+			if got != c.want || ok != c.ok {
+				t.Errorf("retryAfter() = %v, %v, want %v, %v", got, ok, c.want, c.ok)
+			}
+		})
+	}
+}
+
+func TestRetryTransport(t *testing.T) {
+	cases := []struct {
+		name     string
+		method   string
+		statuses []int
+		want     int
+		attempts int32
+	}{
+		{"GET is retried on 502", http.MethodGet, []int{502, 502, 200}, 200, 3},
+		{"GET gives up after max retries", http.MethodGet, []int{503, 503, 503, 503}, 503, 3},
+		{"POST is not retried on 502", http.MethodPost, []int{502, 200}, 502, 1},
+		{"POST is retried on 429", http.MethodPost, []int{429, 200}, 200, 2},
+		{"GET is not retried on 400", http.MethodGet, []int{400, 200}, 400, 1},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				if b, _ := io.ReadAll(r.Body); r.Method == http.MethodPost && string(b) != "body" {
+					t.Errorf("attempt %d got body %q", n, b)
+				}
+				w.WriteHeader(c.statuses[n-1])
+			}))
+			defer srv.Close()
+
+			client := &http.Client{Transport: &RetryTransport{Base: http.DefaultTransport, MaxRetries: 2, Wait: time.Millisecond}}
+			req, err := http.NewRequest(c.method, srv.URL, strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != c.want {
+				t.Errorf("status = %d, want %d", res.StatusCode, c.want)
+			}
+			if got := atomic.LoadInt32(&attempts); got != c.attempts {
+				t.Errorf("attempts = %d, want %d", got, c.attempts)
+			}
+		})
+	}
+}
+
+func TestRetryTransportUnsentPost(t *testing.T) {
+	// Nothing listens on the address, so the connection fails before the request is written
+	srv := httptest.NewServer(http.NotFoundHandler())
+	addr := srv.URL
+	srv.Close()
+
+	var attempts int32
+	base := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&attempts, 1)
+		return http.DefaultTransport.RoundTrip(req)
+	})
+	client := &http.Client{Transport: &RetryTransport{Base: base, MaxRetries: 2, Wait: time.Millisecond}}
+	req, err := http.NewRequest(http.MethodPost, addr, strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("request to a closed server succeeded")
+	}
+	if got := atomic.LoadInt32(&attempts); got != 3 {
+		t.Errorf("attempts = %d, want 3", got)
+	}
+}
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestTrackSend(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	cases := []struct {
+		name string
+		url  string
+		want bool
+	}{
+		{"served", srv.URL, true},
+		{"connection refused", closed.URL, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx, tracker := TrackSend(context.Background())
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, strings.NewReader("body"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			client := &http.Client{Transport: &RetryTransport{Base: &http.Transport{}, MaxRetries: 1, Wait: time.Millisecond}}
+			if res, err := client.Do(req); err == nil {
+				res.Body.Close()
+			}
+			if got := tracker.MaybeSent(); got != c.want {
+				t.Errorf("MaybeSent() = %v, want %v", got, c.want)
+			}
+		})
+	}
+}
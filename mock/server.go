@@ -0,0 +1,236 @@
+package mock
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Lifetime of issued access tokens (seconds)
+const TOKEN_EXPIRES_IN = "86400"
+
+// Mock LINE WORKS server.
+// It serves the OAuth authorize/token endpoints, fixture routes and a subset of the REST API with in-memory state.
+type Server struct {
+	// Reject API requests without an access token issued by this server
+	RequireAuth bool
+
+	mu     sync.Mutex
+	state  *State
+	routes []Route
+	// Issued credentials. Values are scopes.
+	codes         map[string]string
+	accessTokens  map[string]string
+	refreshTokens map[string]string
+}
+
+// Fixed response for a request matched by method and path
+type Route struct {
+	Method string `json:"method"`
+	// URL path. "*" matches a path segment.
+	Path    string            `json:"path"`
+	Status  int               `json:"status"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    json.RawMessage   `json:"body,omitempty"`
+}
+
+// Create mock server
+func NewServer(state *State, routes []Route) *Server {
+	return &Server{
+		state:         state,
+		routes:        routes,
+		codes:         map[string]string{},
+		accessTokens:  map[string]string{},
+		refreshTokens: map[string]string{},
+	}
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch r.URL.Path {
+	case "/oauth2/v2.0/authorize":
+		s.authorize(w, r)
+		return
+	case "/oauth2/v2.0/token":
+		s.token(w, r)
+		return
+	case "/_mock/state":
+		writeJSON(w, http.StatusOK, s.state)
+		return
+	}
+
+	for _, route := range s.routes {
+		if route.match(r) {
+			route.write(w)
+			return
+		}
+	}
+
+	if strings.HasPrefix(r.URL.Path, UPLOAD_PATH_PREFIX) {
+		if s.RequireAuth && !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid access token")
+			return
+		}
+		s.upload(w, r, strings.TrimPrefix(r.URL.Path, UPLOAD_PATH_PREFIX))
+		return
+	}
+
+	if !strings.HasPrefix(r.URL.Path, "/v1.0/") {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "resource not found")
+		return
+	}
+	if s.RequireAuth && !s.authorized(r) {
+		writeError(w, http.StatusUnauthorized, "UNAUTHORIZED", "invalid access token")
+		return
+	}
+	s.api(w, r, strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/v1.0/"), "/"), "/"))
+}
+
+// Authorization endpoint. The request is approved without user interaction.
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil || q.Get("redirect_uri") == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", "invalid redirect_uri")
+		return
+	}
+
+	code := newID()
+	s.codes[code] = q.Get("scope")
+
+	rq := redirectURI.Query()
+	rq.Set("code", code)
+	rq.Set("state", q.Get("state"))
+	redirectURI.RawQuery = rq.Encode()
+	http.Redirect(w, r, redirectURI.String(), http.StatusFound)
+}
+
+// Token endpoint. Supports authorization code, JWT bearer and refresh token grants.
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	}
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_REQUEST", err.Error())
+		return
+	}
+	if r.PostForm.Get("client_id") == "" || r.PostForm.Get("client_secret") == "" {
+		writeError(w, http.StatusUnauthorized, "INVALID_CLIENT", "client_id and client_secret are required")
+		return
+	}
+
+	switch r.PostForm.Get("grant_type") {
+	case "authorization_code":
+		scopes, ok := s.codes[r.PostForm.Get("code")]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "INVALID_GRANT", "invalid code")
+			return
+		}
+		delete(s.codes, r.PostForm.Get("code"))
+		s.issueToken(w, scopes, true)
+	case "urn:ietf:params:oauth:grant-type:jwt-bearer":
+		if strings.Count(r.PostForm.Get("assertion"), ".") != 2 {
+			writeError(w, http.StatusBadRequest, "INVALID_GRANT", "invalid assertion")
+			return
+		}
+		s.issueToken(w, r.PostForm.Get("scope"), true)
+	case "refresh_token":
+		scopes, ok := s.refreshTokens[r.PostForm.Get("refresh_token")]
+		if !ok {
+			writeError(w, http.StatusBadRequest, "INVALID_GRANT", "invalid refresh token")
+			return
+		}
+		s.issueToken(w, scopes, false)
+	default:
+		writeError(w, http.StatusBadRequest, "UNSUPPORTED_GRANT_TYPE", "unsupported grant type")
+	}
+}
+
+func (s *Server) issueToken(w http.ResponseWriter, scopes string, withRefreshToken bool) {
+	accessToken := newID()
+	s.accessTokens[accessToken] = scopes
+	res := map[string]string{
+		"access_token": accessToken,
+		"scope":        scopes,
+		"expires_in":   TOKEN_EXPIRES_IN,
+		"token_type":   "Bearer",
+	}
+	if withRefreshToken {
+		refreshToken := newID()
+		s.refreshTokens[refreshToken] = scopes
+		res["refresh_token"] = refreshToken
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return false
+	}
+	_, ok = s.accessTokens[token]
+	return ok
+}
+
+func (route Route) match(r *http.Request) bool {
+	if route.Method != "" && !strings.EqualFold(route.Method, r.Method) {
+		return false
+	}
+	ok, err := path.Match(route.Path, r.URL.Path)
+	return err == nil && ok
+}
+
+func (route Route) write(w http.ResponseWriter) {
+	for k, v := range route.Headers {
+		w.Header().Set(k, v)
+	}
+	status := route.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	if len(route.Body) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+	if w.Header().Get("Content-Type") == "" {
+		w.Header().Set("Content-Type", "application/json")
+	}
+	w.WriteHeader(status)
+	w.Write(route.Body)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+// Error response in LINE WORKS API format
+func writeError(w http.ResponseWriter, status int, code string, description string) {
+	writeJSON(w, status, map[string]string{
+		"code":        code,
+		"description": description,
+	})
+}
+
+func newID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to generate id: %s", err))
+	}
+	return hex.EncodeToString(b)
+}
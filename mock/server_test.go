@@ -0,0 +1,313 @@
+package mock
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func newTestServer(t *testing.T, routes []Route) (*Server, *httptest.Server) {
+	t.Helper()
+	s := NewServer(DefaultState(), routes)
+	srv := httptest.NewServer(s)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+// Send a request and decode the JSON response into v if it is not nil. Returns the status code.
+func doJSON(t *testing.T, method string, u string, token string, body string, v interface{}) int {
+	t.Helper()
+	req, err := http.NewRequest(method, u, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if v != nil {
+		if err := json.NewDecoder(res.Body).Decode(v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return res.StatusCode
+}
+
+func TestToken(t *testing.T) {
+	cases := []struct {
+		name   string
+		form   url.Values
+		status int
+	}{
+		{
+			"JWT bearer",
+			url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {"a.b.c"}, "client_id": {"id"}, "client_secret": {"s"}, "scope": {"bot"}},
+			http.StatusOK,
+		},
+		{
+			"invalid assertion",
+			url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {"abc"}, "client_id": {"id"}, "client_secret": {"s"}},
+			http.StatusBadRequest,
+		},
+		{
+			"no client secret",
+			url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {"a.b.c"}, "client_id": {"id"}},
+			http.StatusUnauthorized,
+		},
+		{
+			"unknown code",
+			url.Values{"grant_type": {"authorization_code"}, "code": {"x"}, "client_id": {"id"}, "client_secret": {"s"}},
+			http.StatusBadRequest,
+		},
+		{
+			"unknown refresh token",
+			url.Values{"grant_type": {"refresh_token"}, "refresh_token": {"x"}, "client_id": {"id"}, "client_secret": {"s"}},
+			http.StatusBadRequest,
+		},
+		{
+			"unsupported grant type",
+			url.Values{"grant_type": {"password"}, "client_id": {"id"}, "client_secret": {"s"}},
+			http.StatusBadRequest,
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, srv := newTestServer(t, nil)
+			res, err := http.PostForm(srv.URL+"/oauth2/v2.0/token", c.form)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != c.status {
+				t.Errorf("status = %d, want %d", res.StatusCode, c.status)
+			}
+		})
+	}
+}
+
+func TestAuthorizationCodeAndRefresh(t *testing.T) {
+	_, srv := newTestServer(t, nil)
+	client := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+	res, err := client.Get(srv.URL + "/oauth2/v2.0/authorize?redirect_uri=" + url.QueryEscape("http://127.0.0.1/cb") + "&scope=bot&state=st")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	loc, err := url.Parse(res.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.Query().Get("state") != "st" || loc.Query().Get("code") == "" {
+		t.Fatalf("redirected to %s", loc)
+	}
+
+	token := map[string]string{}
+	form := url.Values{"grant_type": {"authorization_code"}, "code": {loc.Query().Get("code")}, "client_id": {"id"}, "client_secret": {"s"}}
+	if status := postForm(t, srv.URL+"/oauth2/v2.0/token", form, &token); status != http.StatusOK {
+		t.Fatalf("token status = %d", status)
+	}
+	if token["scope"] != "bot" || token["refresh_token"] == "" {
+		t.Errorf("token = %v", token)
+	}
+	// The code is used only once
+	if status := postForm(t, srv.URL+"/oauth2/v2.0/token", form, nil); status != http.StatusBadRequest {
+		t.Errorf("second exchange status = %d, want 400", status)
+	}
+
+	refreshed := map[string]string{}
+	form = url.Values{"grant_type": {"refresh_token"}, "refresh_token": {token["refresh_token"]}, "client_id": {"id"}, "client_secret": {"s"}}
+	if status := postForm(t, srv.URL+"/oauth2/v2.0/token", form, &refreshed); status != http.StatusOK {
+		t.Fatalf("refresh status = %d", status)
+	}
+	if refreshed["access_token"] == "" || refreshed["access_token"] == token["access_token"] {
+		t.Errorf("refreshed token = %v", refreshed)
+	}
+}
+
+func postForm(t *testing.T, u string, form url.Values, v interface{}) int {
+	t.Helper()
+	res, err := http.PostForm(u, form)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if v != nil {
+		json.NewDecoder(res.Body).Decode(v)
+	}
+	return res.StatusCode
+}
+
+func TestRequireAuth(t *testing.T) {
+	s, srv := newTestServer(t, nil)
+	s.RequireAuth = true
+
+	token := map[string]string{}
+	form := url.Values{"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"}, "assertion": {"a.b.c"}, "client_id": {"id"}, "client_secret": {"s"}}
+	postForm(t, srv.URL+"/oauth2/v2.0/token", form, &token)
+
+	cases := []struct {
+		name   string
+		token  string
+		status int
+	}{
+		{"issued token", token["access_token"], http.StatusOK},
+		{"no token", "", http.StatusUnauthorized},
+		{"unknown token", "unknown", http.StatusUnauthorized},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if status := doJSON(t, http.MethodGet, srv.URL+"/v1.0/users/me", c.token, "", nil); status != c.status {
+				t.Errorf("status = %d, want %d", status, c.status)
+			}
+		})
+	}
+}
+
+func TestAPI(t *testing.T) {
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"get me", http.MethodGet, "/v1.0/users/me", "", http.StatusOK},
+		{"get user", http.MethodGet, "/v1.0/users/mock-user-1", "", http.StatusOK},
+		{"unknown user", http.MethodGet, "/v1.0/users/nobody", "", http.StatusNotFound},
+		{"get numeric bot ID", http.MethodGet, "/v1.0/bots/2000001", "", http.StatusOK},
+		{"create bot", http.MethodPost, "/v1.0/bots", `{"botName":"b","photoUrl":"https://example.com/a.png"}`, http.StatusCreated},
+		{"create bot without name", http.MethodPost, "/v1.0/bots", `{}`, http.StatusBadRequest},
+		{"send to user", http.MethodPost, "/v1.0/bots/2000001/users/u1/messages", `{"content":{"type":"text","text":"hi"}}`, http.StatusCreated},
+		{"send without content", http.MethodPost, "/v1.0/bots/2000001/channels/c1/messages", `{}`, http.StatusBadRequest},
+		{"send by unknown bot", http.MethodPost, "/v1.0/bots/1/users/u1/messages", `{"content":{}}`, http.StatusNotFound},
+		{"invalid cursor", http.MethodGet, "/v1.0/users?cursor=x", "", http.StatusBadRequest},
+		{"unknown API", http.MethodGet, "/v1.0/groups", "", http.StatusNotFound},
+		{"not API", http.MethodGet, "/other", "", http.StatusNotFound},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, srv := newTestServer(t, nil)
+			if status := doJSON(t, c.method, srv.URL+c.path, "", c.body, nil); status != c.status {
+				t.Errorf("status = %d, want %d", status, c.status)
+			}
+		})
+	}
+}
+
+func TestListPagination(t *testing.T) {
+	s, srv := newTestServer(t, nil)
+	for _, id := range []string{"u2", "u3", "u4", "u5"} {
+		s.state.Users = append(s.state.Users, Resource{"userId": id})
+	}
+
+	ids := []string{}
+	next := "/v1.0/users?count=2"
+	for next != "" {
+		page := struct {
+			Users            []Resource
+			ResponseMetaData struct{ NextCursor *string }
+		}{}
+		if status := doJSON(t, http.MethodGet, srv.URL+next, "", "", &page); status != http.StatusOK {
+			t.Fatalf("status = %d", status)
+		}
+		if len(page.Users) > 2 {
+			t.Errorf("page has %d users", len(page.Users))
+		}
+		for _, u := range page.Users {
+			ids = append(ids, u["userId"].(string))
+		}
+		next = ""
+		if page.ResponseMetaData.NextCursor != nil {
+			next = "/v1.0/users?count=2&cursor=" + *page.ResponseMetaData.NextCursor
+		}
+	}
+	if strings.Join(ids, ",") != "mock-user-1,u2,u3,u4,u5" {
+		t.Errorf("users = %v", ids)
+	}
+}
+
+func TestSendMessageIsRecorded(t *testing.T) {
+	s, srv := newTestServer(t, nil)
+	doJSON(t, http.MethodPost, srv.URL+"/v1.0/bots/2000001/channels/c1/messages", "", `{"content":{"type":"text","text":"hi"}}`, nil)
+
+	if len(s.state.Messages) != 1 {
+		t.Fatalf("%d messages are recorded", len(s.state.Messages))
+	}
+	msg := s.state.Messages[0]
+	if msg.BotID != "2000001" || msg.ChannelID != "c1" || msg.UserID != "" || string(msg.Content) != `{"type":"text","text":"hi"}` {
+		t.Errorf("message = %+v", msg)
+	}
+}
+
+func TestAttachmentUpload(t *testing.T) {
+	s, srv := newTestServer(t, nil)
+	created := map[string]string{}
+	if status := doJSON(t, http.MethodPost, srv.URL+"/v1.0/bots/2000001/attachments", "", `{"fileName":"a.txt"}`, &created); status != http.StatusOK {
+		t.Fatalf("status = %d", status)
+	}
+
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, _ := w.CreateFormFile("Filedata", "a.txt")
+	io.WriteString(part, "hello")
+	w.Close()
+	res, err := http.Post(created["uploadUrl"], w.FormDataContentType(), &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("upload status = %d", res.StatusCode)
+	}
+
+	a := s.state.Attachments[0]
+	if a.FileID != created["fileId"] || !a.Uploaded || a.Size != 5 {
+		t.Errorf("attachment = %+v", a)
+	}
+}
+
+func TestRoutes(t *testing.T) {
+	routes := []Route{
+		{Method: "GET", Path: "/v1.0/users/*", Status: http.StatusTooManyRequests, Headers: map[string]string{"Retry-After": "1"}, Body: json.RawMessage(`{"code":"TOO_MANY_REQUESTS"}`)},
+		{Path: "/v1.0/groups", Status: http.StatusNoContent},
+	}
+	cases := []struct {
+		name   string
+		method string
+		path   string
+		status int
+	}{
+		{"route by pattern", http.MethodGet, "/v1.0/users/mock-user-1", http.StatusTooManyRequests},
+		{"other method falls back to state", http.MethodDelete, "/v1.0/users/nobody", http.StatusNotFound},
+		{"route of any method", http.MethodPost, "/v1.0/groups", http.StatusNoContent},
+		{"pattern matches one segment", http.MethodGet, "/v1.0/users", http.StatusOK},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			_, srv := newTestServer(t, routes)
+			req, _ := http.NewRequest(c.method, srv.URL+c.path, nil)
+			res, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			res.Body.Close()
+			if res.StatusCode != c.status {
+				t.Errorf("status = %d, want %d", res.StatusCode, c.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,507 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// In-memory state of the mock server
+type State struct {
+	Users    []Resource `json:"users"`
+	Bots     []Resource `json:"bots"`
+	Messages []Message  `json:"messages"`
+	// Attachments created by bots
+	Attachments []Attachment `json:"attachments"`
+	// Domain registrations of bots
+	BotDomains []Resource `json:"botDomains"`
+	// Rich menus of bots. The image and users are kept in imageFileId and userIds.
+	Richmenus []Resource `json:"richmenus"`
+
+	seq int
+}
+
+// API resource. Fields are kept as is.
+type Resource map[string]interface{}
+
+// Message sent by a bot
+type Message struct {
+	BotID       string          `json:"botId"`
+	UserID      string          `json:"userId,omitempty"`
+	ChannelID   string          `json:"channelId,omitempty"`
+	Content     json.RawMessage `json:"content"`
+	CreatedTime string          `json:"createdTime"`
+}
+
+// Attachment of a bot. Uploaded is set when the file is uploaded to the upload URL.
+type Attachment struct {
+	FileID      string `json:"fileId"`
+	BotID       string `json:"botId"`
+	FileName    string `json:"fileName"`
+	ContentType string `json:"contentType,omitempty"`
+	Size        int64  `json:"size"`
+	Uploaded    bool   `json:"uploaded"`
+}
+
+// URL path prefix of upload URLs
+const UPLOAD_PATH_PREFIX = "/_mock/uploads/"
+
+const DEFAULT_PAGE_COUNT = 100
+
+// Fixture file names in the fixtures dir
+const FIXTURE_USERS_FILE_NAME = "users.json"
+const FIXTURE_BOTS_FILE_NAME = "bots.json"
+const FIXTURE_ROUTES_FILE_NAME = "routes.json"
+
+// State with a user and a bot
+func DefaultState() *State {
+	return &State{
+		Users: []Resource{{
+			"userId": "mock-user-1",
+			"email":  "user1@example.com",
+			"userName": map[string]interface{}{
+				"lastName":  "Mock",
+				"firstName": "User",
+			},
+		}},
+		Bots: []Resource{{
+			"botId":   2000001,
+			"botName": "Mock Bot",
+		}},
+		Messages:    []Message{},
+		Attachments: []Attachment{},
+	}
+}
+
+// Load state and routes from fixture files in the dir.
+// Missing files are ignored, and the default state is used for them.
+func LoadFixtures(dir string) (*State, []Route, error) {
+	state := DefaultState()
+	routes := []Route{}
+	if dir == "" {
+		return state, routes, nil
+	}
+
+	if err := readFixture(filepath.Join(dir, FIXTURE_USERS_FILE_NAME), &state.Users); err != nil {
+		return nil, nil, err
+	}
+	if err := readFixture(filepath.Join(dir, FIXTURE_BOTS_FILE_NAME), &state.Bots); err != nil {
+		return nil, nil, err
+	}
+	if err := readFixture(filepath.Join(dir, FIXTURE_ROUTES_FILE_NAME), &routes); err != nil {
+		return nil, nil, err
+	}
+	return state, routes, nil
+}
+
+func readFixture(file string, v interface{}) error {
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+type collection struct {
+	// Key of the list in responses (ex. users)
+	name  string
+	idKey string
+	items *[]Resource
+}
+
+// Route REST API requests. segs is the path after /v1.0/.
+func (s *Server) api(w http.ResponseWriter, r *http.Request, segs []string) {
+	users := collection{name: "users", idKey: "userId", items: &s.state.Users}
+	bots := collection{name: "bots", idKey: "botId", items: &s.state.Bots}
+
+	switch {
+	case segs[0] == "users" && len(segs) <= 2:
+		if len(segs) == 2 && segs[1] == "me" && len(s.state.Users) > 0 {
+			segs[1], _ = s.state.Users[0]["userId"].(string)
+		}
+		s.handleCollection(w, r, users, segs[1:])
+	case segs[0] == "bots" && len(segs) == 1 && r.Method == http.MethodPost:
+		s.createBot(w, r, bots)
+	case segs[0] == "bots" && len(segs) <= 2:
+		s.handleCollection(w, r, bots, segs[1:])
+	case segs[0] == "bots" && len(segs) == 4 && segs[2] == "domains":
+		s.botDomain(w, r, segs[1], segs[3])
+	case segs[0] == "bots" && len(segs) >= 3 && segs[2] == "richmenus":
+		s.richmenu(w, r, segs[1], segs[3:])
+	case segs[0] == "bots" && len(segs) == 3 && segs[2] == "attachments":
+		s.createAttachment(w, r, segs[1])
+	case segs[0] == "bots" && len(segs) == 5 && (segs[2] == "users" || segs[2] == "channels") && segs[4] == "messages":
+		s.sendMessage(w, r, segs[1], segs[2], segs[3])
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "resource not found")
+	}
+}
+
+func (s *Server) handleCollection(w http.ResponseWriter, r *http.Request, c collection, rest []string) {
+	if len(rest) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w, r, c)
+		case http.MethodPost:
+			item := Resource{}
+			if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+				writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+				return
+			}
+			if _, ok := item[c.idKey]; !ok {
+				s.state.seq++
+				item[c.idKey] = strconv.Itoa(3000000 + s.state.seq)
+			}
+			*c.items = append(*c.items, item)
+			writeJSON(w, http.StatusCreated, item)
+		default:
+			writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		}
+		return
+	}
+
+	i := c.find(rest[0])
+	if i < 0 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "resource not found")
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, (*c.items)[i])
+	case http.MethodPatch, http.MethodPut:
+		fields := Resource{}
+		if err := json.NewDecoder(r.Body).Decode(&fields); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+			return
+		}
+		if r.Method == http.MethodPut {
+			fields[c.idKey] = (*c.items)[i][c.idKey]
+			(*c.items)[i] = fields
+		} else {
+			for k, v := range fields {
+				if k != c.idKey {
+					(*c.items)[i][k] = v
+				}
+			}
+		}
+		writeJSON(w, http.StatusOK, (*c.items)[i])
+	case http.MethodDelete:
+		*c.items = append((*c.items)[:i], (*c.items)[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+	}
+}
+
+// List with cursor pagination. The cursor is the offset.
+func (s *Server) list(w http.ResponseWriter, r *http.Request, c collection) {
+	count := DEFAULT_PAGE_COUNT
+	if v, err := strconv.Atoi(r.URL.Query().Get("count")); err == nil && v > 0 {
+		count = v
+	}
+	offset := 0
+	if v := r.URL.Query().Get("cursor"); v != "" {
+		o, err := strconv.Atoi(v)
+		if err != nil || o < 0 {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", "invalid cursor")
+			return
+		}
+		offset = o
+	}
+
+	items := *c.items
+	if offset > len(items) {
+		offset = len(items)
+	}
+	end := offset + count
+	if end > len(items) {
+		end = len(items)
+	}
+
+	var nextCursor interface{}
+	if end < len(items) {
+		nextCursor = strconv.Itoa(end)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		c.name: items[offset:end],
+		"responseMetaData": map[string]interface{}{
+			"nextCursor": nextCursor,
+		},
+	})
+}
+
+// Register a bot. The bot secret is issued in the response.
+func (s *Server) createBot(w http.ResponseWriter, r *http.Request, bots collection) {
+	item := Resource{}
+	if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+		return
+	}
+	if name, _ := item["botName"].(string); name == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", "botName is required")
+		return
+	}
+	s.state.seq++
+	// Bot IDs are numbers in the real API
+	item["botId"] = 3000000 + s.state.seq
+	*bots.items = append(*bots.items, item)
+
+	res := Resource{"botSecret": newID()}
+	for k, v := range item {
+		res[k] = v
+	}
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) botDomain(w http.ResponseWriter, r *http.Request, botID string, domainID string) {
+	bots := collection{name: "bots", idKey: "botId", items: &s.state.Bots}
+	if bots.find(botID) < 0 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "bot not found")
+		return
+	}
+	i := -1
+	for j, d := range s.state.BotDomains {
+		if toString(d["botId"]) == botID && toString(d["domainId"]) == domainID {
+			i = j
+		}
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		item := Resource{}
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil && err != io.EOF {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+			return
+		}
+		item["botId"] = botID
+		item["domainId"] = domainID
+		if i < 0 {
+			s.state.BotDomains = append(s.state.BotDomains, item)
+		} else {
+			s.state.BotDomains[i] = item
+		}
+		writeJSON(w, http.StatusCreated, item)
+	case http.MethodGet, http.MethodDelete:
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "bot is not registered to the domain")
+			return
+		}
+		if r.Method == http.MethodGet {
+			writeJSON(w, http.StatusOK, s.state.BotDomains[i])
+			return
+		}
+		s.state.BotDomains = append(s.state.BotDomains[:i], s.state.BotDomains[i+1:]...)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+	}
+}
+
+// Route rich menu requests. rest is the path after bots/{botId}/richmenus.
+func (s *Server) richmenu(w http.ResponseWriter, r *http.Request, botID string, rest []string) {
+	bots := collection{name: "bots", idKey: "botId", items: &s.state.Bots}
+	if bots.find(botID) < 0 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "bot not found")
+		return
+	}
+	// Rich menus of the bot
+	menus := []Resource{}
+	for _, m := range s.state.Richmenus {
+		if toString(m["botId"]) == botID {
+			menus = append(menus, m)
+		}
+	}
+	c := collection{name: "richmenus", idKey: "richmenuId", items: &menus}
+
+	switch {
+	case len(rest) == 0 && r.Method == http.MethodPost:
+		item := Resource{}
+		if err := json.NewDecoder(r.Body).Decode(&item); err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", err.Error())
+			return
+		}
+		s.state.seq++
+		item["richmenuId"] = strconv.Itoa(4000000 + s.state.seq)
+		item["botId"] = botID
+		s.state.Richmenus = append(s.state.Richmenus, item)
+		writeJSON(w, http.StatusCreated, item)
+	case len(rest) == 0 && r.Method == http.MethodGet:
+		s.list(w, r, c)
+	case len(rest) == 2 && rest[0] == "users" && r.Method == http.MethodDelete:
+		for _, m := range menus {
+			m["userIds"] = removeString(m["userIds"], rest[1])
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(rest) == 1 && (r.Method == http.MethodGet || r.Method == http.MethodDelete):
+		i := c.find(rest[0])
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "rich menu not found")
+			return
+		}
+		if r.Method == http.MethodGet {
+			writeJSON(w, http.StatusOK, menus[i])
+			return
+		}
+		for j, m := range s.state.Richmenus {
+			if toString(m["botId"]) == botID && toString(m["richmenuId"]) == rest[0] {
+				s.state.Richmenus = append(s.state.Richmenus[:j], s.state.Richmenus[j+1:]...)
+				break
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case len(rest) == 2 && rest[1] == "image" && r.Method == http.MethodPost:
+		i := c.find(rest[0])
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "rich menu not found")
+			return
+		}
+		body := struct {
+			FileID string `json:"fileId"`
+		}{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.FileID == "" {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", "fileId is required")
+			return
+		}
+		menus[i]["imageFileId"] = body.FileID
+		w.WriteHeader(http.StatusNoContent)
+	case len(rest) == 3 && rest[1] == "users" && r.Method == http.MethodPost:
+		i := c.find(rest[0])
+		if i < 0 {
+			writeError(w, http.StatusNotFound, "NOT_FOUND", "rich menu not found")
+			return
+		}
+		// A user has one rich menu
+		for _, m := range menus {
+			m["userIds"] = removeString(m["userIds"], rest[2])
+		}
+		ids, _ := menus[i]["userIds"].([]interface{})
+		menus[i]["userIds"] = append(ids, rest[2])
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "resource not found")
+	}
+}
+
+// Remove s from a list in a resource field
+func removeString(list interface{}, s string) []interface{} {
+	items, _ := list.([]interface{})
+	kept := []interface{}{}
+	for _, item := range items {
+		if toString(item) != s {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func (s *Server) sendMessage(w http.ResponseWriter, r *http.Request, botID string, kind string, target string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	}
+	bots := collection{name: "bots", idKey: "botId", items: &s.state.Bots}
+	if bots.find(botID) < 0 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "bot not found")
+		return
+	}
+
+	body := struct {
+		Content json.RawMessage `json:"content"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.Content) == 0 {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", "content is required")
+		return
+	}
+
+	msg := Message{
+		BotID:       botID,
+		Content:     body.Content,
+		CreatedTime: time.Now().Format(time.RFC3339),
+	}
+	if kind == "users" {
+		msg.UserID = target
+	} else {
+		msg.ChannelID = target
+	}
+	s.state.Messages = append(s.state.Messages, msg)
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) createAttachment(w http.ResponseWriter, r *http.Request, botID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	}
+	bots := collection{name: "bots", idKey: "botId", items: &s.state.Bots}
+	if bots.find(botID) < 0 {
+		writeError(w, http.StatusNotFound, "NOT_FOUND", "bot not found")
+		return
+	}
+	body := struct {
+		FileName string `json:"fileName"`
+	}{}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.FileName == "" {
+		writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", "fileName is required")
+		return
+	}
+
+	attachment := Attachment{FileID: newID(), BotID: botID, FileName: body.FileName}
+	s.state.Attachments = append(s.state.Attachments, attachment)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"fileId":    attachment.FileID,
+		"uploadUrl": fmt.Sprintf("http://%s%s%s", r.Host, UPLOAD_PATH_PREFIX, attachment.FileID),
+	})
+}
+
+// Receive a file uploaded to the upload URL
+func (s *Server) upload(w http.ResponseWriter, r *http.Request, fileID string) {
+	if r.Method != http.MethodPost {
+		writeError(w, http.StatusMethodNotAllowed, "METHOD_NOT_ALLOWED", "method not allowed")
+		return
+	}
+	for i := range s.state.Attachments {
+		a := &s.state.Attachments[i]
+		if a.FileID != fileID {
+			continue
+		}
+		file, header, err := r.FormFile("Filedata")
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "INVALID_PARAMETER", "Filedata is required")
+			return
+		}
+		defer file.Close()
+		a.ContentType = header.Header.Get("Content-Type")
+		a.Size = header.Size
+		a.Uploaded = true
+		writeJSON(w, http.StatusOK, map[string]string{"fileId": fileID})
+		return
+	}
+	writeError(w, http.StatusNotFound, "NOT_FOUND", "attachment not found")
+}
+
+func (c collection) find(id string) int {
+	for i, item := range *c.items {
+		if v, ok := item[c.idKey]; ok && toString(v) == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func toString(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	b, _ := json.Marshal(v)
+	return string(b)
+}